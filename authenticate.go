@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/auth"
+	"net/http"
+)
+
+// AuthenticatedUserID returns the user named by the request's bearer token.
+func (s *Server) AuthenticatedUserID(r *http.Request) (uuid.UUID, error) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return auth.ValidateJWT(token, s.secret)
+}
+
+// OptionalUserID is AuthenticatedUserID for endpoints that also serve
+// anonymous readers: no Authorization header yields an invalid NullUUID,
+// while a header carrying a bad token is still an error.
+func (s *Server) OptionalUserID(r *http.Request) (uuid.NullUUID, error) {
+	if r.Header.Get("Authorization") == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	userID, err := s.AuthenticatedUserID(r)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	return uuid.NullUUID{UUID: userID, Valid: true}, nil
+}
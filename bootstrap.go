@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/voylento/chirpy/internal/auth"
+	"github.com/voylento/chirpy/internal/database"
+	"github.com/voylento/chirpy/internal/store"
+)
+
+// BootstrapAdmin makes sure the account for email exists and has the admin
+// role, so a fresh deployment has someone who can reach /admin/. The
+// password is only used when the account has to be created.
+func BootstrapAdmin(ctx context.Context, db store.Store, email, password string) (database.User, error) {
+	user, err := db.GetUser(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		if password == "" {
+			return database.User{}, fmt.Errorf("No user with email %s and no password to create one", email)
+		}
+
+		hash, err := auth.HashPassword(password)
+		if err != nil {
+			return database.User{}, err
+		}
+
+		username, err := DefaultUsername()
+		if err != nil {
+			return database.User{}, err
+		}
+
+		user, err = db.CreateUser(ctx, database.CreateUserParams{
+			Email:					email,
+			HashedPassword:	hash,
+			Username:				username,
+		})
+		if err != nil {
+			return database.User{}, err
+		}
+	} else if err != nil {
+		return database.User{}, err
+	}
+
+	if user.Role == auth.RoleAdmin {
+		return user, nil
+	}
+
+	return db.SetUserRole(ctx, database.SetUserRoleParams{
+		ID:		user.ID,
+		Role:	auth.RoleAdmin,
+	})
+}
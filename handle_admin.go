@@ -0,0 +1,92 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/auth"
+	"github.com/voylento/chirpy/internal/database"
+	"net/http"
+)
+
+// MiddlewareRequireAdmin only lets requests through whose bearer token
+// carries the admin role claim and whose account still has that role, so a
+// demotion takes effect without waiting for the token to expire.
+func (s *Server) MiddlewareRequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+			return
+		}
+
+		userID, role, err := auth.ValidateJWTWithRole(token, s.secret)
+		if err != nil {
+			s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+			return
+		}
+
+		if role != auth.RoleAdmin {
+			s.RespondWithError(w, http.StatusForbidden, "Forbidden", nil)
+			return
+		}
+
+		user, err := s.db.GetUserByID(r.Context(), userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+			return
+		}
+		if err != nil {
+			s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve user", err)
+			return
+		}
+
+		if user.Role != auth.RoleAdmin {
+			s.RespondWithError(w, http.StatusForbidden, "Forbidden", nil)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) HandleSetUserRole(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Role	string	`json:"role"`
+	}
+
+	userID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Couldn't decode role parameters", err)
+		return
+	}
+
+	if params.Role != auth.RoleUser && params.Role != auth.RoleAdmin {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid role, must be user or admin", nil)
+		return
+	}
+
+	user, err := s.db.SetUserRole(req.Context(), database.SetUserRoleParams{
+		ID:		userID,
+		Role:	params.Role,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		s.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to set role", err)
+		return
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, NewUser(user))
+}
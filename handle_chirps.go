@@ -1,152 +1,509 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/voylento/chirpy/internal/auth"
+	"github.com/voylento/chirpy/internal/chirptext"
 	"github.com/voylento/chirpy/internal/database"
 	"net/http"
 	"strings"
 	"time"
 )
 
-var prohibitedWords = []string{
-	"kerfuffle",
-	"sharbert",
-	"fornax",
+type Chirp struct {
+	ID						uuid.UUID				`json:"id"`
+	CreatedAt			time.Time				`json:"created_at"`
+	UpdatedAt			time.Time				`json:"updated_at"`
+	Body					string					`json:"body"`
+	UserID				uuid.UUID				`json:"user_id"`
+	Author				*Author					`json:"author"`
+	InReplyTo			uuid.NullUUID		`json:"in_reply_to"`
+	Hashtags			[]string				`json:"hashtags"`
+	Mentions			[]ChirpMention	`json:"mentions"`
+	RechirpOf			*Chirp					`json:"rechirp_of"`
+	QuotedChirp		*Chirp					`json:"quoted_chirp"`
+	QuotedDeleted	bool						`json:"quoted_chirp_deleted"`
+	ReplyCount		int64						`json:"reply_count"`
+	RechirpCount	int64						`json:"rechirp_count"`
+	LikeCount			int64						`json:"like_count"`
+	LikedByMe			bool						`json:"liked_by_me"`
 }
 
-type Chirp struct {
-	ID					uuid.UUID			`json:"id"`
-	CreatedAt		time.Time			`json:"created_at"`
-	UpdatedAt		time.Time			`json:"updated_at"`
-	Body				string				`json:"body"`
-	UserID			uuid.UUID			`json:"user_id"`
+func NewChirp(chirp database.Chirp) Chirp {
+	return Chirp{
+		ID:					chirp.ID,
+		CreatedAt:	chirp.CreatedAt,
+		UpdatedAt:	chirp.UpdatedAt,
+		Body:				chirp.Body,
+		UserID:			chirp.UserID,
+		InReplyTo:	chirp.InReplyTo,
+		Hashtags:		[]string{},
+		Mentions:		[]ChirpMention{},
+	}
 }
 
-func HandleCreateChirp(w http.ResponseWriter, req *http.Request) {
-	type parameters struct {
-		Body 			string 			`json:"body"`
-		UserID 		uuid.UUID		`json:"user_id"`
+// ChirpResponses builds the JSON for chirps along with their engagement
+// counts, embedding the original of each rechirp and quote one level deep.
+// A quote whose original has since been deleted is marked instead.
+// viewerID, when valid, fills in the per-viewer fields.
+func (s *Server) ChirpResponses(ctx context.Context, viewerID uuid.NullUUID, chirps []database.Chirp) ([]Chirp, error) {
+	var referencedIDs []uuid.UUID
+	for _, chirp := range chirps {
+		if chirp.RechirpOf.Valid {
+			referencedIDs = append(referencedIDs, chirp.RechirpOf.UUID)
+		}
+		if chirp.QuotedChirpID.Valid {
+			referencedIDs = append(referencedIDs, chirp.QuotedChirpID.UUID)
+		}
+	}
+
+	var referenced []database.Chirp
+	if len(referencedIDs) > 0 {
+		var err error
+		referenced, err = s.db.GetChirpsByIDs(ctx, referencedIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	all := make([]database.Chirp, 0, len(chirps)+len(referenced))
+	all = append(all, chirps...)
+	all = append(all, referenced...)
+	responses, err := s.flatChirpResponses(ctx, viewerID, all)
+	if err != nil {
+		return nil, err
+	}
+
+	originals := make(map[uuid.UUID]Chirp, len(referenced))
+	for _, response := range responses[len(chirps):] {
+		originals[response.ID] = response
+	}
+
+	responses = responses[:len(chirps)]
+	for i, chirp := range chirps {
+		if original, ok := originals[chirp.RechirpOf.UUID]; ok && chirp.RechirpOf.Valid {
+			responses[i].RechirpOf = &original
+		}
+		if chirp.QuotedChirpID.Valid {
+			if quoted, ok := originals[chirp.QuotedChirpID.UUID]; ok {
+				responses[i].QuotedChirp = &quoted
+			} else {
+				responses[i].QuotedDeleted = true
+			}
+		}
 	}
-	type response struct {
-		ID					uuid.UUID			`json:"id"`
-		CreatedAt		time.Time			`json:"created_at"`
-		UpdatedAt		time.Time			`json:"updated_at"`
-		Body				string				`json:"body"`
-		UserID			uuid.UUID			`json:"user_id"`
+
+	return responses, nil
+}
+
+// flatChirpResponses is ChirpResponses without the embedded originals.
+func (s *Server) flatChirpResponses(ctx context.Context, viewerID uuid.NullUUID, chirps []database.Chirp) ([]Chirp, error) {
+	responses := make([]Chirp, len(chirps))
+	if len(chirps) == 0 {
+		return responses, nil
+	}
+
+	ids := make([]uuid.UUID, len(chirps))
+	for i, chirp := range chirps {
+		ids[i] = chirp.ID
+	}
+
+	counts, err := s.db.GetLikeCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	likeCounts := make(map[uuid.UUID]int64, len(counts))
+	for _, row := range counts {
+		likeCounts[row.ChirpID] = row.LikeCount
+	}
+
+	replies, err := s.db.GetReplyCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	replyCounts := make(map[uuid.UUID]int64, len(replies))
+	for _, row := range replies {
+		replyCounts[row.ChirpID] = row.ReplyCount
+	}
+
+	tags, err := s.db.GetChirpHashtags(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	hashtags := make(map[uuid.UUID][]string)
+	for _, row := range tags {
+		hashtags[row.ChirpID] = append(hashtags[row.ChirpID], row.Tag)
+	}
+
+	mentionRows, err := s.db.GetChirpMentions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	mentions := make(map[uuid.UUID][]ChirpMention)
+	for _, row := range mentionRows {
+		mentions[row.ChirpID] = append(mentions[row.ChirpID], ChirpMention{
+			UserID:	row.UserID,
+			Start:	row.StartOffset,
+			End:		row.EndOffset,
+		})
+	}
+
+	authorIDs := make([]uuid.UUID, 0, len(chirps))
+	seen := make(map[uuid.UUID]bool)
+	for _, chirp := range chirps {
+		if !seen[chirp.UserID] {
+			seen[chirp.UserID] = true
+			authorIDs = append(authorIDs, chirp.UserID)
+		}
+	}
+	users, err := s.db.GetUsersByIDs(ctx, authorIDs)
+	if err != nil {
+		return nil, err
+	}
+	authors := make(map[uuid.UUID]Author, len(users))
+	for _, user := range users {
+		authors[user.ID] = NewAuthor(user)
+	}
+
+	rechirps, err := s.db.GetRechirpCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	rechirpCounts := make(map[uuid.UUID]int64, len(rechirps))
+	for _, row := range rechirps {
+		rechirpCounts[row.ChirpID] = row.RechirpCount
+	}
+
+	likedByMe := make(map[uuid.UUID]bool)
+	if viewerID.Valid {
+		liked, err := s.db.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+			UserID:		viewerID.UUID,
+			ChirpIds:	ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range liked {
+			likedByMe[id] = true
+		}
+	}
+
+	for i, chirp := range chirps {
+		responses[i] = NewChirp(chirp)
+		if tags, ok := hashtags[chirp.ID]; ok {
+			responses[i].Hashtags = tags
+		}
+		if chirpMentions, ok := mentions[chirp.ID]; ok {
+			responses[i].Mentions = chirpMentions
+		}
+		if author, ok := authors[chirp.UserID]; ok {
+			responses[i].Author = &author
+		}
+		responses[i].ReplyCount = replyCounts[chirp.ID]
+		responses[i].RechirpCount = rechirpCounts[chirp.ID]
+		responses[i].LikeCount = likeCounts[chirp.ID]
+		responses[i].LikedByMe = likedByMe[chirp.ID]
+	}
+
+	return responses, nil
+}
+
+type ChirpTooLongError struct {
+	Error				string	`json:"error"`
+	Length			int			`json:"length"`
+	MaxLength		int			`json:"max_length"`
+}
+
+func (s *Server) HandleCreateChirp(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Body 						string 				`json:"body"`
+		UserID 					uuid.UUID			`json:"user_id"`
+		InReplyTo				uuid.NullUUID	`json:"in_reply_to"`
+		QuotedChirpID		uuid.NullUUID	`json:"quoted_chirp_id"`
 	}
 
 	decoder := json.NewDecoder(req.Body)
 	params := parameters{}
 	err := decoder.Decode(&params)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		s.RespondWithError(w, http.StatusRequestEntityTooLarge, "Request body too large", err)
+		return
+	}
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Unable to decode chirp contents", err)
+		s.RespondWithError(w, http.StatusBadRequest, "Unable to decode chirp contents", err)
 		return
 	}
 	
 	token, err := auth.GetBearerToken(req.Header)
 	if err != nil {
-		fmt.Printf("auth.GetBearerToken failed: %v\n", err)
-		RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		s.logger.Printf("auth.GetBearerToken failed: %v\n", err)
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
 		return
 	}
 
-	userId, err := auth.ValidateJWT(token, config.secret)
+	userId, err := auth.ValidateJWT(token, s.secret)
 	if err != nil {
-		fmt.Printf("auth.ValidateJWT failed: %v\n", err)
-		RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		s.logger.Printf("auth.ValidateJWT failed: %v\n", err)
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	length := chirptext.Length(params.Body)
+	if length > s.maxChirpLength {
+		s.RespondWithJSON(w, http.StatusBadRequest, ChirpTooLongError{
+			Error:			fmt.Sprintf("Chirp length exceeds %d", s.maxChirpLength),
+			Length:			length,
+			MaxLength:	s.maxChirpLength,
+		})
 		return
 	}
 
-	const max_chirp_length = 140
-	if len(params.Body) > max_chirp_length {
-		RespondWithError(w, http.StatusBadRequest, "Chirp length exceeds 140", nil)
+	moderated, err := s.ModerateChirp(req.Context(), params.Body)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to load moderation rules", err)
 		return
 	}
 
-	filteredBody := ReplaceProhibitedWords(params.Body)
+	if moderated.Rejected {
+		s.RespondWithError(w, http.StatusBadRequest, "Chirp contains prohibited content", nil)
+		return
+	}
+
+	var parent database.Chirp
+	inReplyTo := params.InReplyTo
+	if inReplyTo.Valid {
+		parent, err = s.db.GetChirp(req.Context(), inReplyTo.UUID)
+		if err == nil && parent.RechirpOf.Valid {
+			inReplyTo = parent.RechirpOf
+			parent, err = s.db.GetChirp(req.Context(), inReplyTo.UUID)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			s.RespondWithError(w, http.StatusBadRequest, "in_reply_to chirp not found", err)
+			return
+		}
+		if err != nil {
+			s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirp", err)
+			return
+		}
+	}
+
+	quotedChirpID := params.QuotedChirpID
+	if quotedChirpID.Valid {
+		quoted, err := s.db.GetChirp(req.Context(), quotedChirpID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			s.RespondWithError(w, http.StatusBadRequest, "quoted_chirp_id chirp not found", err)
+			return
+		}
+		if err != nil {
+			s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirp", err)
+			return
+		}
+		if quoted.RechirpOf.Valid {
+			quotedChirpID = quoted.RechirpOf
+		}
+	}
 
 	chirpParams := database.CreateChirpParams {
-		Body:		filteredBody,
-		UserID:	userId,
+		Body:						moderated.Body,
+		UserID:					userId,
+		InReplyTo:			inReplyTo,
+		QuotedChirpID:	quotedChirpID,
 	}
 
-	chirp, err := config.db.CreateChirp(req.Context(), chirpParams)
+	chirp, err := s.db.CreateChirp(req.Context(), chirpParams)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Unable to create chirp", err)
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to create chirp", err)
 		return
 	}
 
-	RespondWithJSON(w, http.StatusCreated, response {
-		ID:						chirp.ID,
-		CreatedAt:		chirp.CreatedAt,
-		UpdatedAt:		chirp.UpdatedAt,
-		Body: 				chirp.Body,
-		UserID:				chirp.UserID,
-	})
-}
+	if tags := chirptext.Hashtags(chirp.Body); len(tags) > 0 {
+		err = s.db.AddChirpHashtags(req.Context(), database.AddChirpHashtagsParams{
+			ChirpID:	chirp.ID,
+			Tags:			tags,
+		})
+		if err != nil {
+			s.logger.Printf("Unable to index hashtags for chirp %v: %v\n", chirp.ID, err)
+		}
+	}
 
-func ReplaceProhibitedWords(body string) string {
-	words := strings.Fields(body)
+	if err := s.RecordMentions(req.Context(), chirp); err != nil {
+		s.logger.Printf("Unable to record mentions for chirp %v: %v\n", chirp.ID, err)
+	}
 
-	for i, word := range words {
-		wordLowered := strings.ToLower(word)
-		for _, prohibited := range prohibitedWords {
-			if wordLowered == strings.ToLower(prohibited) {
-				words[i] = "****"
-				break
-			}
+	if chirp.InReplyTo.Valid {
+		s.Notify(req.Context(), parent.UserID, userId, NotificationReply, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+	}
+
+	if moderated.Flagged {
+		err = s.db.FlagChirp(req.Context(), database.FlagChirpParams{
+			ChirpID:	chirp.ID,
+			Reason:		"Matched: " + strings.Join(moderated.Matches, ", "),
+		})
+		if err != nil {
+			s.logger.Printf("Unable to flag chirp %v: %v\n", chirp.ID, err)
 		}
 	}
 
-	return strings.Join(words, " ")
+	chirpResponses, err := s.ChirpResponses(req.Context(), uuid.NullUUID{UUID: userId, Valid: true}, []database.Chirp{chirp})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirp", err)
+		return
+	}
+
+	s.chirps.Publish(chirpResponses[0])
+
+	s.RespondWithJSON(w, http.StatusCreated, chirpResponses[0])
 }
 
-func HandleGetChirps(w http.ResponseWriter, r *http.Request) {
-	chirps, err := config.db.GetAllChirps(r.Context())
+func (s *Server) HandleGetChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := s.OptionalUserID(r)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirps", err)
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
 		return
 	}
 
-	chirpResponses := make([]Chirp, len(chirps))
-	for i, chirp := range chirps {
-		chirpResponses[i] = Chirp{
-			ID:					chirp.ID,
-			CreatedAt:	chirp.CreatedAt,
-			UpdatedAt:	chirp.UpdatedAt,
-			Body:				chirp.Body,
-			UserID:			chirp.UserID,
+	authorIDStr := r.URL.Query().Get("author_id")
+	sortOrder := r.URL.Query().Get("sort")
+	if sortOrder == "" {
+		sortOrder = "asc"
+	}
+
+	if sortOrder != "asc" && sortOrder != "desc" {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid sort, must be asc or desc", nil)
+		return
+	}
+
+	authorID := uuid.NullUUID{}
+	if authorIDStr != "" {
+		id, err := uuid.Parse(authorIDStr)
+		if err != nil {
+			s.RespondWithError(w, http.StatusBadRequest, "Invalid author_id", err)
+			return
 		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	page, err := ParsePage(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	var chirps []database.Chirp
+	if sortOrder == "desc" {
+		chirps, err = s.db.GetChirpsPageDesc(r.Context(), database.GetChirpsPageDescParams{
+			AuthorID:					authorID,
+			BeforeCreatedAt:	page.AfterCreatedAt(),
+			BeforeID:					page.AfterID(),
+			PageLimit:				page.QueryLimit(),
+		})
+	} else {
+		chirps, err = s.db.GetChirpsPage(r.Context(), database.GetChirpsPageParams{
+			AuthorID:					authorID,
+			AfterCreatedAt:		page.AfterCreatedAt(),
+			AfterID:					page.AfterID(),
+			PageLimit:				page.QueryLimit(),
+		})
+	}
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirps", err)
+		return
+	}
+
+	if len(chirps) > page.Limit {
+		chirps = chirps[:page.Limit]
+		last := chirps[len(chirps)-1]
+		SetNextLink(w, r, page.Limit, Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	chirpResponses, err := s.ChirpResponses(r.Context(), viewerID, chirps)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirps", err)
+		return
 	}
 
-	RespondWithJSON(w, http.StatusOK, chirpResponses) 
+	s.RespondWithJSON(w, http.StatusOK, chirpResponses) 
 }
 
-func HandleGetChirp(w http.ResponseWriter, r *http.Request) {
+func (s *Server) HandleGetChirp(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := s.OptionalUserID(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
 	chirpIDStr := r.PathValue("chirpID")
 
 	chirpID, err := uuid.Parse(chirpIDStr)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Invalid id", err)
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid id", err)
 		return
 	}
 
-	chirp, err := config.db.GetChirp(r.Context(), chirpID)
+	chirp, err := s.db.GetChirp(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.RespondWithError(w, http.StatusNotFound, "Not Found", err)
+		return
+	}
 	if err != nil {
-		RespondWithError(w, http.StatusNotFound, "Not Found", err)
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirp", err)
 		return
 	}
 
-	response := Chirp{
-		ID:					chirp.ID,
-		CreatedAt:	chirp.CreatedAt,
-		UpdatedAt:	chirp.UpdatedAt,
-		Body:				chirp.Body,
-		UserID:			chirp.UserID,
+	chirpResponses, err := s.ChirpResponses(r.Context(), viewerID, []database.Chirp{chirp})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirp", err)
+		return
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, chirpResponses[0]) 
+}
+
+func (s *Server) HandleDeleteChirp(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, s.secret)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+
+	chirp, err := s.db.GetChirp(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.RespondWithError(w, http.StatusNotFound, "Not Found", err)
+		return
+	}
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirp", err)
+		return
+	}
+
+	if chirp.UserID != userID {
+		s.RespondWithError(w, http.StatusForbidden, "Forbidden", nil)
+		return
+	}
+
+	err = s.db.DeleteChirp(r.Context(), chirpID)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to delete chirp", err)
+		return
 	}
 
-	RespondWithJSON(w, http.StatusOK, response) 
+	RespondWithStatusCode(w, http.StatusNoContent)
 }
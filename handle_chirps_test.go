@@ -0,0 +1,243 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/chirptext"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func createChirp(t *testing.T, s *Server, user loggedInUser, body string) Chirp {
+	t.Helper()
+
+	rec := doRequest(t, s, http.MethodPost, "/api/chirps", user.bearer(), map[string]string{
+		"body":	body,
+	})
+	expectStatus(t, rec, http.StatusCreated)
+
+	var chirp Chirp
+	decodeBody(t, rec, &chirp)
+	return chirp
+}
+
+func TestHandleCreateChirp(t *testing.T) {
+	s := newTestServer(t)
+	user := createAndLogin(t, s, "a@example.com", "password1")
+	admin := createAndLogin(t, s, "admin@example.com", "password1", asAdmin())
+	createModerationRule(t, s, admin, "kerfuffle", "mask")
+	createModerationRule(t, s, admin, "sharbert", "mask")
+
+	chirp := createChirp(t, s, user, "I had a kerfuffle with a Sharbert! today")
+	if chirp.UserID != user.ID {
+		t.Fatalf("Expected chirp owned by %v, got %v", user.ID, chirp.UserID)
+	}
+	if chirp.Body != "I had a **** with a ****! today" {
+		t.Fatalf("Expected prohibited words masked, got %q", chirp.Body)
+	}
+}
+
+func TestHandleCreateChirp_Errors(t *testing.T) {
+	s := newTestServer(t)
+	user := createAndLogin(t, s, "a@example.com", "password1")
+
+	tests := []struct {
+		name		string
+		auth		string
+		body		any
+		status	int
+	}{
+		{
+			name:		"Malformed JSON",
+			auth:		user.bearer(),
+			body:		`{"body":`,
+			status:	http.StatusBadRequest,
+		},
+		{
+			name:		"Missing token",
+			auth:		"",
+			body:		map[string]string{"body": "hello"},
+			status:	http.StatusUnauthorized,
+		},
+		{
+			name:		"Invalid token",
+			auth:		"Bearer not.a.jwt",
+			body:		map[string]string{"body": "hello"},
+			status:	http.StatusUnauthorized,
+		},
+		{
+			name:		"Chirp too long",
+			auth:		user.bearer(),
+			body:		map[string]string{"body": strings.Repeat("a", 141)},
+			status:	http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, s, http.MethodPost, "/api/chirps", tt.auth, tt.body)
+			expectStatus(t, rec, tt.status)
+		})
+	}
+}
+
+func TestHandleCreateChirp_Length(t *testing.T) {
+	s := newTestServer(t)
+	user := createAndLogin(t, s, "a@example.com", "password1")
+
+	createChirp(t, s, user, strings.Repeat("\U0001f600", 140))
+	createChirp(t, s, user, "read this https://example.com/"+strings.Repeat("a", 200))
+
+	rec := doRequest(t, s, http.MethodPost, "/api/chirps", user.bearer(), map[string]string{
+		"body":	strings.Repeat("\U0001f600", 141),
+	})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	var tooLong ChirpTooLongError
+	decodeBody(t, rec, &tooLong)
+	if tooLong.Length != 141 || tooLong.MaxLength != 140 {
+		t.Fatalf("Expected length 141 and max 140, got %+v", tooLong)
+	}
+
+	// Many bytes per character is fine as long as the characters fit.
+	createChirp(t, s, user, strings.Repeat("\U0001f468\u200d\U0001f469\u200d\U0001f467\u200d\U0001f466", 140))
+
+	// A link too long for the flat weight counts every character.
+	rec = doRequest(t, s, http.MethodPost, "/api/chirps", user.bearer(), map[string]string{
+		"body":	"https://example.com/" + strings.Repeat("a", chirptext.MaxURLBytes),
+	})
+	expectStatus(t, rec, http.StatusBadRequest)
+	decodeBody(t, rec, &tooLong)
+	if tooLong.Length != len("https://example.com/")+chirptext.MaxURLBytes || tooLong.MaxLength != 140 {
+		t.Fatalf("Expected the oversized link counted in full, got %+v", tooLong)
+	}
+
+	rec = doRequest(t, s, http.MethodPost, "/api/chirps", user.bearer(), map[string]string{
+		"body":	strings.Repeat("a", maxRequestBodyBytes),
+	})
+	expectStatus(t, rec, http.StatusRequestEntityTooLarge)
+
+	s.maxChirpLength = 10
+	rec = doRequest(t, s, http.MethodPost, "/api/chirps", user.bearer(), map[string]string{
+		"body":	"eleven char",
+	})
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestHandleCreateChirp_Mentions(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1", withUsername("alice"))
+	bob := createAndLogin(t, s, "b@example.com", "password1", withUsername("bob"))
+
+	chirp := createChirp(t, s, alice, "hey @Bob and @nobody, cc @alice")
+	want := []ChirpMention{
+		{UserID: bob.ID, Start: 4, End: 8},
+		{UserID: alice.ID, Start: 25, End: 31},
+	}
+	if !reflect.DeepEqual(chirp.Mentions, want) {
+		t.Fatalf("Expected mentions %+v, got %+v", want, chirp.Mentions)
+	}
+
+	plain := createChirp(t, s, alice, "mail me at alice@example.com")
+	if plain.Mentions == nil || len(plain.Mentions) != 0 {
+		t.Fatalf("Expected no mentions, got %#v", plain.Mentions)
+	}
+}
+
+func TestHandleGetChirp(t *testing.T) {
+	s := newTestServer(t)
+	user := createAndLogin(t, s, "a@example.com", "password1")
+	chirp := createChirp(t, s, user, "hello")
+
+	rec := doRequest(t, s, http.MethodGet, "/api/chirps/"+chirp.ID.String(), "", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var got Chirp
+	decodeBody(t, rec, &got)
+	if got.ID != chirp.ID || got.Body != "hello" {
+		t.Fatalf("Expected chirp %v, got %+v", chirp.ID, got)
+	}
+	if got.Author == nil || got.Author.ID != user.ID || got.Author.Username != user.Username {
+		t.Fatalf("Expected author %v embedded, got %+v", user.ID, got.Author)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps/"+uuid.NewString(), "", nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps/not-a-uuid", "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestHandleGetChirps(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+
+	createChirp(t, s, alice, "first")
+	createChirp(t, s, bob, "second")
+	createChirp(t, s, alice, "third")
+
+	rec := doRequest(t, s, http.MethodGet, "/api/chirps", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var all []Chirp
+	decodeBody(t, rec, &all)
+	if len(all) != 3 {
+		t.Fatalf("Expected 3 chirps, got %d", len(all))
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps?author_id="+alice.ID.String()+"&sort=desc", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var aliceChirps []Chirp
+	decodeBody(t, rec, &aliceChirps)
+	if len(aliceChirps) != 2 {
+		t.Fatalf("Expected 2 chirps by author, got %d", len(aliceChirps))
+	}
+	if aliceChirps[0].CreatedAt.Before(aliceChirps[1].CreatedAt) {
+		t.Fatalf("Expected chirps sorted newest first")
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps?limit=2", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var page []Chirp
+	decodeBody(t, rec, &page)
+	if len(page) != 2 {
+		t.Fatalf("Expected 2 chirps on first page, got %d", len(page))
+	}
+
+	rec = doRequest(t, s, http.MethodGet, nextLink(t, rec.Header().Get("Link")), "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &page)
+	if len(page) != 1 {
+		t.Fatalf("Expected 1 chirp on second page, got %d", len(page))
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps?sort=sideways", "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps?author_id=not-a-uuid", "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestHandleDeleteChirp(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+	chirp := createChirp(t, s, alice, "hello")
+	path := "/api/chirps/" + chirp.ID.String()
+
+	rec := doRequest(t, s, http.MethodDelete, path, "", nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = doRequest(t, s, http.MethodDelete, path, bob.bearer(), nil)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = doRequest(t, s, http.MethodDelete, "/api/chirps/"+uuid.NewString(), alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = doRequest(t, s, http.MethodDelete, path, alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = doRequest(t, s, http.MethodGet, path, "", nil)
+	expectStatus(t, rec, http.StatusNotFound)
+}
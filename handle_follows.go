@@ -0,0 +1,220 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/database"
+	"net/http"
+	"time"
+)
+
+type Follow struct {
+	PublicUser
+	FollowedAt	time.Time	`json:"followed_at"`
+}
+
+// NewFollow builds a listing entry. Follower listings are public, so the row
+// only carries public profile columns to begin with.
+func NewFollow(row database.GetFollowersPageRow) Follow {
+	return Follow{
+		PublicUser: PublicUser{
+			ID:						row.ID,
+			CreatedAt:		row.CreatedAt,
+			Username:			row.Username,
+			IsChirpyRed:	row.IsChirpyRed,
+			DisplayName:	row.DisplayName,
+			Bio:					row.Bio,
+			Location:			row.Location,
+			Website:			row.Website,
+			AvatarURL:		row.AvatarURL,
+		},
+		FollowedAt:	row.FollowedAt,
+	}
+}
+
+func (s *Server) HandleFollowUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.AuthenticatedUserID(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+
+	if followeeID == userID {
+		s.RespondWithError(w, http.StatusBadRequest, "Users cannot follow themselves", nil)
+		return
+	}
+
+	_, err = s.db.GetUserByID(r.Context(), followeeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve user", err)
+		return
+	}
+
+	followed, err := s.db.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID:	userID,
+		FolloweeID:	followeeID,
+	})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to follow user", err)
+		return
+	}
+
+	if followed > 0 {
+		s.followChanged(userID, followeeID, true)
+		s.Notify(r.Context(), followeeID, userID, NotificationFollow, uuid.NullUUID{})
+	}
+
+	RespondWithStatusCode(w, http.StatusNoContent)
+}
+
+func (s *Server) HandleUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.AuthenticatedUserID(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+
+	err = s.db.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID:	userID,
+		FolloweeID:	followeeID,
+	})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to unfollow user", err)
+		return
+	}
+	s.followChanged(userID, followeeID, false)
+
+	RespondWithStatusCode(w, http.StatusNoContent)
+}
+
+func (s *Server) HandleGetFollowers(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+
+	page, err := ParsePage(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := s.db.GetFollowersPage(r.Context(), database.GetFollowersPageParams{
+		UserID:						userID,
+		AfterFollowedAt:	page.AfterCreatedAt(),
+		AfterID:					page.AfterID(),
+		PageLimit:				page.QueryLimit(),
+	})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve followers", err)
+		return
+	}
+
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		SetNextLink(w, r, page.Limit, Cursor{CreatedAt: last.FollowedAt, ID: last.ID})
+	}
+
+	follows := make([]Follow, len(rows))
+	for i, row := range rows {
+		follows[i] = NewFollow(row)
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, follows)
+}
+
+func (s *Server) HandleGetFollowing(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+
+	page, err := ParsePage(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := s.db.GetFollowingPage(r.Context(), database.GetFollowingPageParams{
+		UserID:						userID,
+		AfterFollowedAt:	page.AfterCreatedAt(),
+		AfterID:					page.AfterID(),
+		PageLimit:				page.QueryLimit(),
+	})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve followed users", err)
+		return
+	}
+
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		SetNextLink(w, r, page.Limit, Cursor{CreatedAt: last.FollowedAt, ID: last.ID})
+	}
+
+	follows := make([]Follow, len(rows))
+	for i, row := range rows {
+		follows[i] = NewFollow(database.GetFollowersPageRow(row))
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, follows)
+}
+
+func (s *Server) HandleGetTimeline(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.AuthenticatedUserID(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	page, err := ParsePage(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	chirps, err := s.db.GetTimelinePage(r.Context(), database.GetTimelinePageParams{
+		UserID:						userID,
+		BeforeCreatedAt:	page.AfterCreatedAt(),
+		BeforeID:					page.AfterID(),
+		PageLimit:				page.QueryLimit(),
+	})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve timeline", err)
+		return
+	}
+
+	if len(chirps) > page.Limit {
+		chirps = chirps[:page.Limit]
+		last := chirps[len(chirps)-1]
+		SetNextLink(w, r, page.Limit, Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	chirpResponses, err := s.ChirpResponses(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirps)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve timeline", err)
+		return
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, chirpResponses)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func follow(t *testing.T, s *Server, follower, followee loggedInUser) {
+	t.Helper()
+	rec := doRequest(t, s, http.MethodPost, "/api/users/"+followee.ID.String()+"/follow", follower.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+}
+
+func TestHandleFollowUser(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+
+	follow(t, s, alice, bob)
+	follow(t, s, alice, bob)
+
+	rec := doRequest(t, s, http.MethodGet, "/api/users/"+bob.ID.String()+"/followers", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var followers []Follow
+	decodeBody(t, rec, &followers)
+	if strings.Contains(rec.Body.String(), "@example.com") {
+		t.Fatalf("Expected follower listing to hide emails, got %s", rec.Body.String())
+	}
+	if len(followers) != 1 || followers[0].ID != alice.ID {
+		t.Fatalf("Expected alice as bob's only follower, got %+v", followers)
+	}
+	if followers[0].Username != alice.Username {
+		t.Fatalf("Expected follower username %q, got %q", alice.Username, followers[0].Username)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/users/"+alice.ID.String()+"/following", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var following []Follow
+	decodeBody(t, rec, &following)
+	if len(following) != 1 || following[0].ID != bob.ID {
+		t.Fatalf("Expected alice to follow only bob, got %+v", following)
+	}
+
+	rec = doRequest(t, s, http.MethodDelete, "/api/users/"+bob.ID.String()+"/follow", alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/users/"+bob.ID.String()+"/followers", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &followers)
+	if len(followers) != 0 {
+		t.Fatalf("Expected no followers after unfollow, got %d", len(followers))
+	}
+}
+
+func TestHandleFollowUser_Errors(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+
+	tests := []struct {
+		name		string
+		path		string
+		auth		string
+		status	int
+	}{
+		{
+			name:		"Missing token",
+			path:		"/api/users/" + uuid.NewString() + "/follow",
+			auth:		"",
+			status:	http.StatusUnauthorized,
+		},
+		{
+			name:		"Unknown user",
+			path:		"/api/users/" + uuid.NewString() + "/follow",
+			auth:		alice.bearer(),
+			status:	http.StatusNotFound,
+		},
+		{
+			name:		"Self follow",
+			path:		"/api/users/" + alice.ID.String() + "/follow",
+			auth:		alice.bearer(),
+			status:	http.StatusBadRequest,
+		},
+		{
+			name:		"Malformed id",
+			path:		"/api/users/not-a-uuid/follow",
+			auth:		alice.bearer(),
+			status:	http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, s, http.MethodPost, tt.path, tt.auth, nil)
+			expectStatus(t, rec, tt.status)
+		})
+	}
+}
+
+func TestHandleGetTimeline(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+	carol := createAndLogin(t, s, "c@example.com", "password1")
+
+	follow(t, s, alice, bob)
+	createChirp(t, s, bob, "bob one")
+	createChirp(t, s, carol, "carol one")
+	createChirp(t, s, bob, "bob two")
+	createChirp(t, s, alice, "alice one")
+
+	rec := doRequest(t, s, http.MethodGet, "/api/timeline?limit=1", alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var timeline []Chirp
+	decodeBody(t, rec, &timeline)
+	if len(timeline) != 1 || timeline[0].Body != "bob two" {
+		t.Fatalf("Expected newest followed chirp first, got %+v", timeline)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, nextLink(t, rec.Header().Get("Link")), alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &timeline)
+	if len(timeline) != 1 || timeline[0].Body != "bob one" {
+		t.Fatalf("Expected older followed chirp on second page, got %+v", timeline)
+	}
+	if rec.Header().Get("Link") != "" {
+		t.Fatalf("Expected no more pages, got %s", rec.Header().Get("Link"))
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/timeline", "", nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"github.com/voylento/chirpy/internal/chirptext"
+	"github.com/voylento/chirpy/internal/database"
+	"net/http"
+)
+
+func (s *Server) HandleGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := s.OptionalUserID(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	tag := chirptext.NormalizeHashtag(r.PathValue("tag"))
+	if tag == "" {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid hashtag", nil)
+		return
+	}
+
+	page, err := ParsePage(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	chirps, err := s.db.GetHashtagChirpsPage(r.Context(), database.GetHashtagChirpsPageParams{
+		Tag:							tag,
+		BeforeCreatedAt:	page.AfterCreatedAt(),
+		BeforeID:					page.AfterID(),
+		PageLimit:				page.QueryLimit(),
+	})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirps", err)
+		return
+	}
+
+	if len(chirps) > page.Limit {
+		chirps = chirps[:page.Limit]
+		last := chirps[len(chirps)-1]
+		SetNextLink(w, r, page.Limit, Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	chirpResponses, err := s.ChirpResponses(r.Context(), viewerID, chirps)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirps", err)
+		return
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, chirpResponses)
+}
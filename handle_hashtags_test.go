@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHandleGetHashtagChirps(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+
+	first := createChirp(t, s, alice, "learning #Go and #SQL")
+	if !reflect.DeepEqual(first.Hashtags, []string{"go", "sql"}) {
+		t.Fatalf("Expected hashtags [go sql], got %q", first.Hashtags)
+	}
+	createChirp(t, s, alice, "more #go")
+	createChirp(t, s, alice, "#going elsewhere")
+	plain := createChirp(t, s, alice, "no tags here")
+	if plain.Hashtags == nil || len(plain.Hashtags) != 0 {
+		t.Fatalf("Expected empty hashtags, got %#v", plain.Hashtags)
+	}
+
+	rec := doRequest(t, s, http.MethodGet, "/api/hashtags/GO/chirps?limit=1", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var page []Chirp
+	decodeBody(t, rec, &page)
+	if len(page) != 1 || page[0].Body != "more #go" {
+		t.Fatalf("Expected newest #go chirp first, got %+v", page)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, nextLink(t, rec.Header().Get("Link")), "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &page)
+	if len(page) != 1 || page[0].ID != first.ID {
+		t.Fatalf("Expected first #go chirp on second page, got %+v", page)
+	}
+	if rec.Header().Get("Link") != "" {
+		t.Fatalf("Expected no next link on last page")
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/hashtags/123/chirps", "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = doRequest(t, s, http.MethodDelete, "/api/chirps/"+first.ID.String(), alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/hashtags/sql/chirps", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &page)
+	if len(page) != 0 {
+		t.Fatalf("Expected deleted chirp removed from hashtag feed, got %+v", page)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/database"
+	"net/http"
+)
+
+func (s *Server) HandleLikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.AuthenticatedUserID(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+
+	// Liking a rechirp likes the original, as rechirping one does.
+	chirp, err := s.db.GetChirp(r.Context(), chirpID)
+	if err == nil && chirp.RechirpOf.Valid {
+		chirp, err = s.db.GetChirp(r.Context(), chirp.RechirpOf.UUID)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		s.RespondWithError(w, http.StatusNotFound, "Not Found", err)
+		return
+	}
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirp", err)
+		return
+	}
+
+	liked, err := s.db.LikeChirp(r.Context(), database.LikeChirpParams{
+		UserID:		userID,
+		ChirpID:	chirp.ID,
+	})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to like chirp", err)
+		return
+	}
+
+	if liked > 0 {
+		s.Notify(r.Context(), chirp.UserID, userID, NotificationLike, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+	}
+
+	RespondWithStatusCode(w, http.StatusNoContent)
+}
+
+func (s *Server) HandleUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.AuthenticatedUserID(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+
+	chirp, err := s.db.GetChirp(r.Context(), chirpID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirp", err)
+		return
+	}
+	if err == nil && chirp.RechirpOf.Valid {
+		chirpID = chirp.RechirpOf.UUID
+	}
+
+	err = s.db.UnlikeChirp(r.Context(), database.UnlikeChirpParams{
+		UserID:		userID,
+		ChirpID:	chirpID,
+	})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to unlike chirp", err)
+		return
+	}
+
+	RespondWithStatusCode(w, http.StatusNoContent)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"net/http"
+	"testing"
+)
+
+func TestHandleLikeChirp(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+	chirp := createChirp(t, s, alice, "hello")
+	path := "/api/chirps/" + chirp.ID.String()
+
+	rec := doRequest(t, s, http.MethodPut, path+"/like", "", nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = doRequest(t, s, http.MethodPut, "/api/chirps/"+uuid.NewString()+"/like", bob.bearer(), nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = doRequest(t, s, http.MethodPut, "/api/chirps/not-a-uuid/like", bob.bearer(), nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	for i := 0; i < 2; i++ {
+		rec = doRequest(t, s, http.MethodPut, path+"/like", bob.bearer(), nil)
+		expectStatus(t, rec, http.StatusNoContent)
+	}
+
+	var got Chirp
+	rec = doRequest(t, s, http.MethodGet, path, bob.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &got)
+	if got.LikeCount != 1 || !got.LikedByMe {
+		t.Fatalf("Expected 1 like by viewer, got %+v", got)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, path, alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &got)
+	if got.LikeCount != 1 || got.LikedByMe {
+		t.Fatalf("Expected 1 like not by viewer, got %+v", got)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var all []Chirp
+	decodeBody(t, rec, &all)
+	if len(all) != 1 || all[0].LikeCount != 1 || all[0].LikedByMe {
+		t.Fatalf("Expected anonymous listing with 1 like, got %+v", all)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps", "Bearer not.a.jwt", nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	for i := 0; i < 2; i++ {
+		rec = doRequest(t, s, http.MethodDelete, path+"/like", bob.bearer(), nil)
+		expectStatus(t, rec, http.StatusNoContent)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, path, bob.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &got)
+	if got.LikeCount != 0 || got.LikedByMe {
+		t.Fatalf("Expected no likes after unlike, got %+v", got)
+	}
+}
+
+func TestHandleLikeChirp_Rechirp(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+	carol := createAndLogin(t, s, "c@example.com", "password1")
+	original := createChirp(t, s, alice, "original")
+
+	rec := doRequest(t, s, http.MethodPost, "/api/chirps/"+original.ID.String()+"/rechirp", bob.bearer(), nil)
+	expectStatus(t, rec, http.StatusCreated)
+	var rechirp Chirp
+	decodeBody(t, rec, &rechirp)
+
+	rec = doRequest(t, s, http.MethodPut, "/api/chirps/"+rechirp.ID.String()+"/like", carol.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	var got Chirp
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps/"+original.ID.String(), carol.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &got)
+	if got.LikeCount != 1 || !got.LikedByMe {
+		t.Fatalf("Expected the like on the original, got %+v", got)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps/"+rechirp.ID.String(), carol.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &got)
+	if got.LikeCount != 0 {
+		t.Fatalf("Expected no like on the rechirp itself, got %+v", got)
+	}
+
+	var notifications []Notification
+	rec = doRequest(t, s, http.MethodGet, "/api/notifications", alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &notifications)
+	liked := false
+	for _, n := range notifications {
+		if n.Kind == NotificationLike && n.ActorID == carol.ID && n.ChirpID.UUID == original.ID {
+			liked = true
+		}
+	}
+	if !liked {
+		t.Fatalf("Expected alice to be notified of the like, got %+v", notifications)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/notifications", bob.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &notifications)
+	for _, n := range notifications {
+		if n.Kind == NotificationLike {
+			t.Fatalf("Expected the rechirper not to be notified of the like, got %+v", n)
+		}
+	}
+
+	rec = doRequest(t, s, http.MethodDelete, "/api/chirps/"+rechirp.ID.String()+"/like", carol.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps/"+original.ID.String(), carol.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &got)
+	if got.LikeCount != 0 || got.LikedByMe {
+		t.Fatalf("Expected unliking the rechirp to unlike the original, got %+v", got)
+	}
+}
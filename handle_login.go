@@ -1,12 +1,17 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"github.com/voylento/chirpy/internal/auth"
+	"github.com/voylento/chirpy/internal/database"
 	"net/http"
 	"time"
 )
 
+const refreshTokenExpiration = 60 * 24 * time.Hour
+
 type Login struct {
 	Email				string	`json:"email"`
 	Password		string	`json:"password"`
@@ -14,24 +19,28 @@ type Login struct {
 }
 
 
-func HandleLogin(w http.ResponseWriter, req *http.Request) {
+func (s *Server) HandleLogin(w http.ResponseWriter, req *http.Request) {
 	decoder := json.NewDecoder(req.Body)
 	params := Login{}
 	err := decoder.Decode(&params)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Couldn't decode user parameters", err)
+		s.RespondWithError(w, http.StatusBadRequest, "Couldn't decode user parameters", err)
 		return
 	}
 
-	user, err := config.db.GetUser(req.Context(), params.Email)
+	user, err := s.db.GetUser(req.Context(), params.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.RespondWithError(w, http.StatusUnauthorized, "Incorrect email or password", err)
+		return
+	}
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Internal Server Error", err)
+		s.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error", err)
 		return
 	}
 
 	err = auth.CheckPasswordHash(params.Password, user.HashedPassword)
 	if err != nil { 
-		RespondWithJSON(w, http.StatusUnauthorized, "Incorrect email or password")
+		s.RespondWithError(w, http.StatusUnauthorized, "Incorrect email or password", err)
 		return
 	}
 
@@ -41,24 +50,37 @@ func HandleLogin(w http.ResponseWriter, req *http.Request) {
 		expiresSeconds = params.Expires
 	}
 
-	token, err := auth.MakeJWT(user.ID, config.secret, time.Duration(expiresSeconds)*time.Second)
+	token, err := auth.MakeJWT(user.ID, user.Role, s.secret, time.Duration(expiresSeconds)*time.Second)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Failed to make JWT", err)
+		return
+	}
+
+	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Failed to make refresh token", err)
+		return
+	}
+
+	_, err = s.db.CreateRefreshToken(req.Context(), database.CreateRefreshTokenParams{
+		Token:			refreshToken,
+		UserID:			user.ID,
+		ExpiresAt:	s.now().UTC().Add(refreshTokenExpiration),
+	})
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Failed to make JWT", err)
+		s.RespondWithError(w, http.StatusInternalServerError, "Failed to save refresh token", err)
 		return
 	}
 
 	response := struct{
 		User
-		Token	string	`json:"token"`
+		Token					string	`json:"token"`
+		RefreshToken	string	`json:"refresh_token"`
 	}{
-		User: User{
-			ID:				user.ID,
-			CreatedAt:	user.CreatedAt,
-			UpdatedAt:	user.UpdatedAt,
-			Email:			user.Email,
-		},
-		Token: token,
+		User:					NewUser(user),
+		Token:				token,
+		RefreshToken:	refreshToken,
 	}
-	RespondWithJSON(w, http.StatusOK, response)
+	s.RespondWithJSON(w, http.StatusOK, response)
 }
 
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"github.com/voylento/chirpy/internal/auth"
+	"net/http"
+	"testing"
+)
+
+func TestHandleLogin(t *testing.T) {
+	s := newTestServer(t)
+	user := createAndLogin(t, s, "a@example.com", "password1")
+
+	if user.Token == "" || user.RefreshToken == "" {
+		t.Fatalf("Expected access and refresh tokens, got %+v", user)
+	}
+
+	userID, err := auth.ValidateJWT(user.Token, testSecret)
+	if err != nil {
+		t.Fatalf("Expected valid access token, got %v", err)
+	}
+	if userID != user.ID {
+		t.Fatalf("Expected token subject %v, got %v", user.ID, userID)
+	}
+}
+
+func TestHandleLogin_Errors(t *testing.T) {
+	s := newTestServer(t)
+	createAndLogin(t, s, "a@example.com", "password1")
+
+	tests := []struct {
+		name		string
+		body		any
+		status	int
+	}{
+		{
+			name:		"Malformed JSON",
+			body:		`{"email":`,
+			status:	http.StatusBadRequest,
+		},
+		{
+			name:		"Wrong password",
+			body:		map[string]string{"email": "a@example.com", "password": "wrong"},
+			status:	http.StatusUnauthorized,
+		},
+		{
+			name:		"Unknown email",
+			body:		map[string]string{"email": "nobody@example.com", "password": "password1"},
+			status:	http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, s, http.MethodPost, "/api/login", "", tt.body)
+			expectStatus(t, rec, tt.status)
+		})
+	}
+}
+
+func TestHandleRefreshAndRevoke(t *testing.T) {
+	s := newTestServer(t)
+	user := createAndLogin(t, s, "a@example.com", "password1")
+	refresh := "Bearer " + user.RefreshToken
+
+	rec := doRequest(t, s, http.MethodPost, "/api/refresh", refresh, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var body struct {
+		Token	string	`json:"token"`
+	}
+	decodeBody(t, rec, &body)
+	if _, err := auth.ValidateJWT(body.Token, testSecret); err != nil {
+		t.Fatalf("Expected refreshed access token to validate, got %v", err)
+	}
+
+	rec = doRequest(t, s, http.MethodPost, "/api/revoke", refresh, nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/refresh", refresh, nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+}
+
+func TestHandleRefresh_Errors(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := doRequest(t, s, http.MethodPost, "/api/refresh", "", nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/refresh", "Bearer unknown", nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/revoke", "", nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+}
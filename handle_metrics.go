@@ -5,7 +5,7 @@ import (
 	"net/http"
 )
 
-func (config *Config) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+func (s *Server) HandleMetrics(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "text/html")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(fmt.Sprintf(`
@@ -14,12 +14,12 @@ func (config *Config) HandleMetrics(w http.ResponseWriter, r *http.Request) {
 	  <h1>Welcome, Chirpy Admin</h1> 
 		<p>Chirpy has been visited %d times!</p> 
 	</body> 
-</html>`, config.hits.Load())))
+</html>`, s.hits.Load())))
 }
 
-func (config *Config) MiddlewareMetricsInc(next http.Handler) http.Handler {
+func (s *Server) MiddlewareMetricsInc(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		config.hits.Add(1)
+		s.hits.Add(1)
 		next.ServeHTTP(w, r)
 	})
 }
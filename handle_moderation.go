@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/database"
+	"github.com/voylento/chirpy/internal/moderation"
+	"github.com/voylento/chirpy/internal/store"
+	"net/http"
+	"time"
+)
+
+type ModerationRule struct {
+	ID					uuid.UUID		`json:"id"`
+	CreatedAt		time.Time		`json:"created_at"`
+	UpdatedAt		time.Time		`json:"updated_at"`
+	Word				string			`json:"word"`
+	Action			string			`json:"action"`
+}
+
+type FlaggedChirp struct {
+	Chirp
+	Reason			string			`json:"reason"`
+	FlaggedAt		time.Time		`json:"flagged_at"`
+}
+
+func (s *Server) ModerateChirp(ctx context.Context, body string) (moderation.Result, error) {
+	dbRules, err := s.db.ListModerationRules(ctx)
+	if err != nil {
+		return moderation.Result{}, err
+	}
+
+	rules := make([]moderation.Rule, len(dbRules))
+	for i, rule := range dbRules {
+		rules[i] = moderation.Rule{
+			Word:		rule.Word,
+			Action:	moderation.Action(rule.Action),
+		}
+	}
+
+	return moderation.Apply(body, rules), nil
+}
+
+func (s *Server) HandleListModerationRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.db.ListModerationRules(r.Context())
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve moderation rules", err)
+		return
+	}
+
+	ruleResponses := make([]ModerationRule, len(rules))
+	for i, rule := range rules {
+		ruleResponses[i] = ModerationRule{
+			ID:					rule.ID,
+			CreatedAt:	rule.CreatedAt,
+			UpdatedAt:	rule.UpdatedAt,
+			Word:				rule.Word,
+			Action:			rule.Action,
+		}
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, ruleResponses)
+}
+
+func (s *Server) HandleCreateModerationRule(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Word		string	`json:"word"`
+		Action	string	`json:"action"`
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Couldn't decode rule parameters", err)
+		return
+	}
+
+	word, err := moderation.NormalizeWord(params.Word)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	action, err := moderation.ParseAction(params.Action)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rule, err := s.db.CreateModerationRule(req.Context(), database.CreateModerationRuleParams{
+		Word:		word,
+		Action:	string(action),
+	})
+	if store.IsUniqueViolation(err) {
+		s.RespondWithError(w, http.StatusConflict, "Rule for word already exists", err)
+		return
+	}
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to create moderation rule", err)
+		return
+	}
+
+	s.RespondWithJSON(w, http.StatusCreated, ModerationRule{
+		ID:					rule.ID,
+		CreatedAt:	rule.CreatedAt,
+		UpdatedAt:	rule.UpdatedAt,
+		Word:				rule.Word,
+		Action:			rule.Action,
+	})
+}
+
+func (s *Server) HandleDeleteModerationRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := uuid.Parse(r.PathValue("ruleID"))
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+
+	deleted, err := s.db.DeleteModerationRule(r.Context(), ruleID)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to delete moderation rule", err)
+		return
+	}
+
+	if deleted == 0 {
+		s.RespondWithError(w, http.StatusNotFound, "Not Found", nil)
+		return
+	}
+
+	RespondWithStatusCode(w, http.StatusNoContent)
+}
+
+func (s *Server) HandleListFlaggedChirps(w http.ResponseWriter, r *http.Request) {
+	flagged, err := s.db.ListFlaggedChirps(r.Context())
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve flagged chirps", err)
+		return
+	}
+
+	chirps := make([]database.Chirp, len(flagged))
+	for i, row := range flagged {
+		chirps[i] = database.Chirp{
+			ID:							row.ID,
+			CreatedAt:			row.CreatedAt,
+			UpdatedAt:			row.UpdatedAt,
+			Body:						row.Body,
+			UserID:					row.UserID,
+			InReplyTo:			row.InReplyTo,
+			RechirpOf:			row.RechirpOf,
+			QuotedChirpID:	row.QuotedChirpID,
+		}
+	}
+
+	chirpResponses, err := s.ChirpResponses(r.Context(), uuid.NullUUID{}, chirps)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve flagged chirps", err)
+		return
+	}
+
+	flaggedResponses := make([]FlaggedChirp, len(flagged))
+	for i, row := range flagged {
+		flaggedResponses[i] = FlaggedChirp{
+			Chirp:			chirpResponses[i],
+			Reason:			row.Reason,
+			FlaggedAt:	row.FlaggedAt,
+		}
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, flaggedResponses)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"net/http"
+	"testing"
+)
+
+func createModerationRule(t *testing.T, s *Server, admin loggedInUser, word, action string) ModerationRule {
+	t.Helper()
+
+	rec := doRequest(t, s, http.MethodPost, "/admin/moderation/rules", admin.bearer(), map[string]string{
+		"word":		word,
+		"action":	action,
+	})
+	expectStatus(t, rec, http.StatusCreated)
+
+	var rule ModerationRule
+	decodeBody(t, rec, &rule)
+	return rule
+}
+
+func TestModerationRules(t *testing.T) {
+	s := newTestServer(t)
+	admin := createAndLogin(t, s, "admin@example.com", "password1", asAdmin())
+	rule := createModerationRule(t, s, admin, "Fornax", "reject")
+	if rule.Word != "fornax" {
+		t.Fatalf("Expected rule word to be lowercased, got %q", rule.Word)
+	}
+
+	tests := []struct {
+		name		string
+		body		any
+		status	int
+	}{
+		{
+			name:		"Duplicate word",
+			body:		map[string]string{"word": "FORNAX", "action": "mask"},
+			status:	http.StatusConflict,
+		},
+		{
+			name:		"Unknown action",
+			body:		map[string]string{"word": "gizmo", "action": "explode"},
+			status:	http.StatusBadRequest,
+		},
+		{
+			name:		"Multiple words",
+			body:		map[string]string{"word": "two words", "action": "mask"},
+			status:	http.StatusBadRequest,
+		},
+		{
+			name:		"Malformed JSON",
+			body:		`{"word":`,
+			status:	http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, s, http.MethodPost, "/admin/moderation/rules", admin.bearer(), tt.body)
+			expectStatus(t, rec, tt.status)
+		})
+	}
+
+	rec := doRequest(t, s, http.MethodGet, "/admin/moderation/rules", admin.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var rules []ModerationRule
+	decodeBody(t, rec, &rules)
+	if len(rules) != 1 {
+		t.Fatalf("Expected 1 rule, got %d", len(rules))
+	}
+
+	rec = doRequest(t, s, http.MethodDelete, "/admin/moderation/rules/"+rule.ID.String(), admin.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = doRequest(t, s, http.MethodDelete, "/admin/moderation/rules/"+uuid.NewString(), admin.bearer(), nil)
+	expectStatus(t, rec, http.StatusNotFound)
+}
+
+func TestModerationRules_RequireAdmin(t *testing.T) {
+	s := newTestServer(t)
+	user := createAndLogin(t, s, "a@example.com", "password1")
+	body := map[string]string{
+		"word":		"fornax",
+		"action":	"reject",
+	}
+
+	rec := doRequest(t, s, http.MethodPost, "/admin/moderation/rules", "", body)
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = doRequest(t, s, http.MethodPost, "/admin/moderation/rules", user.bearer(), body)
+	expectStatus(t, rec, http.StatusForbidden)
+}
+
+func TestHandleCreateChirp_Moderation(t *testing.T) {
+	s := newTestServer(t)
+	user := createAndLogin(t, s, "a@example.com", "password1")
+	admin := createAndLogin(t, s, "admin@example.com", "password1", asAdmin())
+	createModerationRule(t, s, admin, "fornax", "reject")
+	createModerationRule(t, s, admin, "gizmo", "flag")
+
+	rec := doRequest(t, s, http.MethodPost, "/api/chirps", user.bearer(), map[string]string{
+		"body":	"FORNAX!!",
+	})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	chirp := createChirp(t, s, user, "Look at my Gizmo. #gadgets")
+	if chirp.Body != "Look at my Gizmo. #gadgets" {
+		t.Fatalf("Expected flagged chirp body unchanged, got %q", chirp.Body)
+	}
+	rec = doRequest(t, s, http.MethodPut, "/api/chirps/"+chirp.ID.String()+"/like", admin.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = doRequest(t, s, http.MethodGet, "/admin/moderation/flagged", admin.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var flagged []FlaggedChirp
+	decodeBody(t, rec, &flagged)
+	if len(flagged) != 1 || flagged[0].ID != chirp.ID {
+		t.Fatalf("Expected chirp %v to be flagged, got %+v", chirp.ID, flagged)
+	}
+	got := flagged[0]
+	if got.LikeCount != 1 || got.Author == nil || got.Author.ID != user.ID || len(got.Hashtags) != 1 || got.Mentions == nil {
+		t.Fatalf("Expected a full chirp response for the flagged chirp, got %+v", got)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/database"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Notification struct {
+	ID					uuid.UUID			`json:"id"`
+	CreatedAt		time.Time			`json:"created_at"`
+	Kind				string				`json:"kind"`
+	ActorID			uuid.UUID			`json:"actor_id"`
+	ChirpID			uuid.NullUUID	`json:"chirp_id"`
+	ReadAt			*time.Time		`json:"read_at"`
+}
+
+func NewNotification(notification database.Notification) Notification {
+	response := Notification{
+		ID:					notification.ID,
+		CreatedAt:	notification.CreatedAt,
+		Kind:				notification.Kind,
+		ActorID:		notification.ActorID,
+		ChirpID:		notification.ChirpID,
+	}
+	if notification.ReadAt.Valid {
+		response.ReadAt = &notification.ReadAt.Time
+	}
+	return response
+}
+
+// HandleGetNotifications lists the authenticated user's notifications,
+// newest first. ?unread=true limits the page to ones not yet marked read.
+func (s *Server) HandleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.AuthenticatedUserID(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	unreadOnly := false
+	if unread := r.URL.Query().Get("unread"); unread != "" {
+		unreadOnly, err = strconv.ParseBool(unread)
+		if err != nil {
+			s.RespondWithError(w, http.StatusBadRequest, "Invalid unread, must be true or false", err)
+			return
+		}
+	}
+
+	page, err := ParsePage(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	notifications, err := s.db.GetNotificationsPage(r.Context(), database.GetNotificationsPageParams{
+		UserID:						userID,
+		UnreadOnly:				unreadOnly,
+		BeforeCreatedAt:	page.AfterCreatedAt(),
+		BeforeID:					page.AfterID(),
+		PageLimit:				page.QueryLimit(),
+	})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve notifications", err)
+		return
+	}
+
+	if len(notifications) > page.Limit {
+		notifications = notifications[:page.Limit]
+		last := notifications[len(notifications)-1]
+		SetNextLink(w, r, page.Limit, Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	notificationResponses := make([]Notification, len(notifications))
+	for i, notification := range notifications {
+		notificationResponses[i] = NewNotification(notification)
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, notificationResponses)
+}
+
+// HandleMarkNotificationsRead marks the notifications listed in "ids" as
+// read, or every unread notification when the body or the list is empty.
+func (s *Server) HandleMarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		IDs		[]uuid.UUID		`json:"ids"`
+	}
+
+	userID, err := s.AuthenticatedUserID(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	params := parameters{}
+	err = json.NewDecoder(r.Body).Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		s.RespondWithError(w, http.StatusBadRequest, "Unable to decode notification ids", err)
+		return
+	}
+
+	_, err = s.db.MarkNotificationsRead(r.Context(), database.MarkNotificationsReadParams{
+		UserID:	userID,
+		Ids:		params.IDs,
+	})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to mark notifications read", err)
+		return
+	}
+
+	RespondWithStatusCode(w, http.StatusNoContent)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func getNotifications(t *testing.T, s *Server, user loggedInUser, query string) []Notification {
+	t.Helper()
+
+	rec := doRequest(t, s, http.MethodGet, "/api/notifications"+query, user.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var notifications []Notification
+	decodeBody(t, rec, &notifications)
+	return notifications
+}
+
+func TestHandleGetNotifications(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1", withUsername("alice"))
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+
+	chirp := createChirp(t, s, alice, "hello")
+
+	follow(t, s, bob, alice)
+	follow(t, s, bob, alice)
+	for i := 0; i < 2; i++ {
+		rec := doRequest(t, s, http.MethodPut, "/api/chirps/"+chirp.ID.String()+"/like", bob.bearer(), nil)
+		expectStatus(t, rec, http.StatusNoContent)
+	}
+	reply := createReply(t, s, bob, chirp, "hi @alice")
+	createReply(t, s, alice, chirp, "talking to myself")
+
+	notifications := getNotifications(t, s, alice, "")
+	kinds := make(map[string]int)
+	for _, notification := range notifications {
+		kinds[notification.Kind]++
+		if notification.ActorID != bob.ID || notification.ReadAt != nil {
+			t.Fatalf("Expected unread notification from bob, got %+v", notification)
+		}
+		if notification.Kind == NotificationReply && notification.ChirpID.UUID != reply.ID {
+			t.Fatalf("Expected reply notification to reference the reply, got %+v", notification)
+		}
+	}
+	want := map[string]int{
+		NotificationReply:		1,
+		NotificationMention:	1,
+		NotificationLike:			1,
+		NotificationFollow:		1,
+	}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("Expected notifications %v, got %v", want, kinds)
+	}
+
+	if got := getNotifications(t, s, bob, ""); len(got) != 0 {
+		t.Fatalf("Expected bob to have no notifications, got %+v", got)
+	}
+
+	rec := doRequest(t, s, http.MethodGet, "/api/notifications?limit=3", alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	rec = doRequest(t, s, http.MethodGet, nextLink(t, rec.Header().Get("Link")), alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var page []Notification
+	decodeBody(t, rec, &page)
+	if len(page) != 1 {
+		t.Fatalf("Expected 1 notification on second page, got %d", len(page))
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/notifications", "", nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/notifications?unread=maybe", alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestHandleMarkNotificationsRead(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+	carol := createAndLogin(t, s, "c@example.com", "password1")
+
+	follow(t, s, bob, alice)
+	follow(t, s, carol, alice)
+
+	notifications := getNotifications(t, s, alice, "?unread=true")
+	if len(notifications) != 2 {
+		t.Fatalf("Expected 2 unread notifications, got %d", len(notifications))
+	}
+
+	rec := doRequest(t, s, http.MethodPost, "/api/notifications/read", bob.bearer(), map[string]any{
+		"ids":	[]any{notifications[0].ID},
+	})
+	expectStatus(t, rec, http.StatusNoContent)
+	if got := getNotifications(t, s, alice, "?unread=true"); len(got) != 2 {
+		t.Fatalf("Expected another user's mark to have no effect, got %d unread", len(got))
+	}
+
+	rec = doRequest(t, s, http.MethodPost, "/api/notifications/read", alice.bearer(), map[string]any{
+		"ids":	[]any{notifications[0].ID},
+	})
+	expectStatus(t, rec, http.StatusNoContent)
+
+	unread := getNotifications(t, s, alice, "?unread=true")
+	if len(unread) != 1 || unread[0].ID != notifications[1].ID {
+		t.Fatalf("Expected only the second notification unread, got %+v", unread)
+	}
+
+	rec = doRequest(t, s, http.MethodPost, "/api/notifications/read", alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	if got := getNotifications(t, s, alice, "?unread=true"); len(got) != 0 {
+		t.Fatalf("Expected no unread notifications, got %+v", got)
+	}
+	all := getNotifications(t, s, alice, "")
+	if len(all) != 2 || all[0].ReadAt == nil || all[1].ReadAt == nil {
+		t.Fatalf("Expected both notifications marked read, got %+v", all)
+	}
+
+	rec = doRequest(t, s, http.MethodPost, "/api/notifications/read", alice.bearer(), `{"ids":`)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/database"
+	"github.com/voylento/chirpy/internal/store"
+	"net/http"
+)
+
+// HandleRechirp reposts a chirp for the authenticated user. Rechirping a
+// rechirp reposts the original it points at.
+func (s *Server) HandleRechirp(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.AuthenticatedUserID(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+
+	original, err := s.db.GetChirp(r.Context(), chirpID)
+	if err == nil && original.RechirpOf.Valid {
+		original, err = s.db.GetChirp(r.Context(), original.RechirpOf.UUID)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		s.RespondWithError(w, http.StatusNotFound, "Not Found", err)
+		return
+	}
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirp", err)
+		return
+	}
+
+	rechirp, err := s.db.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		UserID:			userID,
+		RechirpOf:	uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	if store.IsUniqueViolation(err) {
+		s.RespondWithError(w, http.StatusConflict, "Chirp already rechirped", err)
+		return
+	}
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to rechirp", err)
+		return
+	}
+
+	chirpResponses, err := s.ChirpResponses(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, []database.Chirp{rechirp})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirp", err)
+		return
+	}
+
+	// The embedded original carries the rechirper's liked_by_me, so
+	// subscribers get the same viewer-less rendering a replay would.
+	published, err := s.ChirpResponses(r.Context(), uuid.NullUUID{}, []database.Chirp{rechirp})
+	if err != nil {
+		s.logger.Printf("Unable to publish rechirp %v: %v\n", rechirp.ID, err)
+	} else {
+		s.chirps.Publish(published[0])
+	}
+
+	s.RespondWithJSON(w, http.StatusCreated, chirpResponses[0])
+}
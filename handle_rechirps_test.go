@@ -0,0 +1,110 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"net/http"
+	"testing"
+)
+
+func TestHandleRechirp(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+	carol := createAndLogin(t, s, "c@example.com", "password1")
+	original := createChirp(t, s, alice, "original")
+
+	rec := doRequest(t, s, http.MethodPost, "/api/chirps/"+original.ID.String()+"/rechirp", "", nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/chirps/"+uuid.NewString()+"/rechirp", bob.bearer(), nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/chirps/"+original.ID.String()+"/rechirp", bob.bearer(), nil)
+	expectStatus(t, rec, http.StatusCreated)
+
+	var rechirp Chirp
+	decodeBody(t, rec, &rechirp)
+	if rechirp.UserID != bob.ID || rechirp.RechirpOf == nil {
+		t.Fatalf("Expected rechirp by bob embedding the original, got %+v", rechirp)
+	}
+	if rechirp.RechirpOf.UserID != alice.ID || rechirp.RechirpOf.Body != "original" {
+		t.Fatalf("Expected original author and body, got %+v", rechirp.RechirpOf)
+	}
+
+	rec = doRequest(t, s, http.MethodPost, "/api/chirps/"+original.ID.String()+"/rechirp", bob.bearer(), nil)
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/chirps/"+rechirp.ID.String()+"/rechirp", carol.bearer(), nil)
+	expectStatus(t, rec, http.StatusCreated)
+	var chained Chirp
+	decodeBody(t, rec, &chained)
+	if chained.RechirpOf == nil || chained.RechirpOf.ID != original.ID {
+		t.Fatalf("Expected rechirp of a rechirp to point at the original, got %+v", chained.RechirpOf)
+	}
+
+	follow(t, s, carol, bob)
+	rec = doRequest(t, s, http.MethodGet, "/api/timeline", carol.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var timeline []Chirp
+	decodeBody(t, rec, &timeline)
+	if len(timeline) != 1 || timeline[0].RechirpOf == nil || timeline[0].RechirpOf.Body != "original" {
+		t.Fatalf("Expected timeline to render bob's rechirp, got %+v", timeline)
+	}
+	if timeline[0].RechirpOf.RechirpCount != 2 {
+		t.Fatalf("Expected original to have 2 rechirps, got %d", timeline[0].RechirpOf.RechirpCount)
+	}
+
+	reply := createReply(t, s, carol, rechirp, "replying to a rechirp")
+	if !reply.InReplyTo.Valid || reply.InReplyTo.UUID != original.ID {
+		t.Fatalf("Expected reply to a rechirp to point at the original, got %+v", reply.InReplyTo)
+	}
+
+	rec = doRequest(t, s, http.MethodDelete, "/api/chirps/"+original.ID.String(), alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps/"+rechirp.ID.String(), "", nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var all []Chirp
+	decodeBody(t, rec, &all)
+	if len(all) != 1 || all[0].ID != reply.ID || all[0].InReplyTo.Valid {
+		t.Fatalf("Expected rechirps removed with the original and the reply detached, got %+v", all)
+	}
+}
+
+func TestHandleCreateChirp_Quote(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+	original := createChirp(t, s, alice, "original")
+
+	rec := doRequest(t, s, http.MethodPost, "/api/chirps", bob.bearer(), map[string]any{
+		"body":							"so true",
+		"quoted_chirp_id":	original.ID,
+	})
+	expectStatus(t, rec, http.StatusCreated)
+
+	var quote Chirp
+	decodeBody(t, rec, &quote)
+	if quote.QuotedChirp == nil || quote.QuotedChirp.ID != original.ID || quote.QuotedChirp.UserID != alice.ID || quote.QuotedDeleted {
+		t.Fatalf("Expected quote to embed the original, got %+v", quote.QuotedChirp)
+	}
+
+	rec = doRequest(t, s, http.MethodPost, "/api/chirps", bob.bearer(), map[string]any{
+		"body":							"missing",
+		"quoted_chirp_id":	uuid.NewString(),
+	})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = doRequest(t, s, http.MethodDelete, "/api/chirps/"+original.ID.String(), alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps/"+quote.ID.String(), "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &quote)
+	if quote.Body != "so true" || quote.QuotedChirp != nil || !quote.QuotedDeleted {
+		t.Fatalf("Expected quote to survive with the original marked deleted, got %+v", quote)
+	}
+}
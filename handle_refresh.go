@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/voylento/chirpy/internal/auth"
+	"net/http"
+	"time"
+)
+
+func (s *Server) HandleRefresh(w http.ResponseWriter, req *http.Request) {
+	refreshToken, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	user, err := s.db.GetUserFromRefreshToken(req.Context(), refreshToken)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	token, err := auth.MakeJWT(user.ID, user.Role, s.secret, time.Hour)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Failed to make JWT", err)
+		return
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, struct{
+		Token	string	`json:"token"`
+	}{
+		Token: token,
+	})
+}
+
+func (s *Server) HandleRevoke(w http.ResponseWriter, req *http.Request) {
+	refreshToken, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	err = s.db.RevokeRefreshToken(req.Context(), refreshToken)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to revoke refresh token", err)
+		return
+	}
+
+	RespondWithStatusCode(w, http.StatusNoContent)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/database"
+	"github.com/voylento/chirpy/internal/search"
+	"net/http"
+	"time"
+)
+
+// HandleSearchChirps finds chirps matching ?q=, best match first. The query
+// supports "quoted phrases" and prefix* words; author_id, since and until
+// narrow the results.
+func (s *Server) HandleSearchChirps(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := s.OptionalUserID(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	query, err := search.Parse(r.URL.Query().Get("q"))
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	authorID := uuid.NullUUID{}
+	if authorIDStr := r.URL.Query().Get("author_id"); authorIDStr != "" {
+		id, err := uuid.Parse(authorIDStr)
+		if err != nil {
+			s.RespondWithError(w, http.StatusBadRequest, "Invalid author_id", err)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	since, err := parseTimeParam(r, "since")
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid since, must be RFC 3339", err)
+		return
+	}
+
+	until, err := parseTimeParam(r, "until")
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid until, must be RFC 3339", err)
+		return
+	}
+
+	page, err := ParsePage(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if page.After != nil && page.After.Rank == nil {
+		s.RespondWithError(w, http.StatusBadRequest, ErrorInvalidCursor.Error(), ErrorInvalidCursor)
+		return
+	}
+
+	rows, err := s.db.SearchChirpsPage(r.Context(), database.SearchChirpsPageParams{
+		Query:						query.TSQuery(),
+		AuthorID:					authorID,
+		Since:						since,
+		Until:						until,
+		BeforeRank:				page.AfterRank(),
+		BeforeCreatedAt:	page.AfterCreatedAt(),
+		BeforeID:					page.AfterID(),
+		PageLimit:				page.QueryLimit(),
+	})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to search chirps", err)
+		return
+	}
+
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		SetNextLink(w, r, page.Limit, Cursor{CreatedAt: last.CreatedAt, ID: last.ID, Rank: &last.Rank})
+	}
+
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, database.Chirp{
+			ID:							row.ID,
+			CreatedAt:			row.CreatedAt,
+			UpdatedAt:			row.UpdatedAt,
+			Body:						row.Body,
+			UserID:					row.UserID,
+			InReplyTo:			row.InReplyTo,
+			RechirpOf:			row.RechirpOf,
+			QuotedChirpID:	row.QuotedChirpID,
+		})
+	}
+
+	chirpResponses, err := s.ChirpResponses(r.Context(), viewerID, chirps)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirps", err)
+		return
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, chirpResponses)
+}
+
+func parseTimeParam(r *http.Request, name string) (sql.NullTime, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
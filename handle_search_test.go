@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func searchChirps(t *testing.T, s *Server, query url.Values) []Chirp {
+	t.Helper()
+
+	rec := doRequest(t, s, http.MethodGet, "/api/search/chirps?"+query.Encode(), "", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var chirps []Chirp
+	decodeBody(t, rec, &chirps)
+	return chirps
+}
+
+func TestHandleSearchChirps(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+
+	once := createChirp(t, s, alice, "good morning, coffee first")
+	twice := createChirp(t, s, bob, "coffee coffee and a good morning")
+	createChirp(t, s, alice, "morning is good")
+	chirping := createChirp(t, s, bob, "chirping about birds")
+
+	got := searchChirps(t, s, url.Values{"q": {`"good morning" coffee`}})
+	if len(got) != 2 || got[0].ID != twice.ID || got[1].ID != once.ID {
+		t.Fatalf("Expected phrase matches ranked by relevance, got %+v", got)
+	}
+
+	got = searchChirps(t, s, url.Values{"q": {"chirp*"}})
+	if len(got) != 1 || got[0].ID != chirping.ID {
+		t.Fatalf("Expected prefix match %v, got %+v", chirping.ID, got)
+	}
+
+	got = searchChirps(t, s, url.Values{"q": {"coffee"}, "author_id": {alice.ID.String()}})
+	if len(got) != 1 || got[0].ID != once.ID {
+		t.Fatalf("Expected author filter to leave %v, got %+v", once.ID, got)
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	got = searchChirps(t, s, url.Values{"q": {"coffee"}, "since": {future}})
+	if len(got) != 0 {
+		t.Fatalf("Expected no chirps since %s, got %+v", future, got)
+	}
+	got = searchChirps(t, s, url.Values{"q": {"coffee"}, "until": {future}})
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 chirps until %s, got %+v", future, got)
+	}
+
+	rec := doRequest(t, s, http.MethodGet, "/api/search/chirps?q=good&limit=2", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var page []Chirp
+	decodeBody(t, rec, &page)
+	seen := map[string]bool{}
+	for _, chirp := range page {
+		seen[chirp.ID.String()] = true
+	}
+
+	rec = doRequest(t, s, http.MethodGet, nextLink(t, rec.Header().Get("Link")), "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &page)
+	if len(page) != 1 || seen[page[0].ID.String()] {
+		t.Fatalf("Expected one new chirp on the second page, got %+v", page)
+	}
+}
+
+func TestHandleSearchChirps_Errors(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	chirp := createChirp(t, s, alice, "hello")
+	plainCursor := EncodeCursor(Cursor{CreatedAt: chirp.CreatedAt, ID: chirp.ID})
+
+	for _, query := range []url.Values{
+		{},
+		{"q": {"!!!"}},
+		{"q": {"hello"}, "author_id": {"not-a-uuid"}},
+		{"q": {"hello"}, "since": {"yesterday"}},
+		{"q": {"hello"}, "until": {"2025-01-01"}},
+		{"q": {"hello"}, "cursor": {plainCursor}},
+	} {
+		rec := doRequest(t, s, http.MethodGet, "/api/search/chirps?"+query.Encode(), "", nil)
+		expectStatus(t, rec, http.StatusBadRequest)
+	}
+}
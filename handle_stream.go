@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/chirptext"
+	"github.com/voylento/chirpy/internal/database"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	defaultHeartbeatInterval	= 15 * time.Second
+	streamBufferSize					= 64
+	maxReplayAge							= time.Hour
+)
+
+type streamFilter struct {
+	authorID	uuid.NullUUID
+	hashtag		string
+}
+
+func (f streamFilter) match(chirp Chirp) bool {
+	if f.authorID.Valid && chirp.UserID != f.authorID.UUID {
+		return false
+	}
+	if f.hashtag != "" && !slices.Contains(chirp.Hashtags, f.hashtag) {
+		return false
+	}
+	return true
+}
+
+// HandleStream pushes newly created chirps as server-sent events. Each event
+// id is the chirp's cursor, so a reconnecting client that sends Last-Event-ID
+// is first sent whatever it missed, as long as that is no older than
+// maxReplayAge. Past that the client gets a 400 and should reload through
+// the paginated API before streaming again. A client too slow to keep up is
+// disconnected and expected to resume the same way.
+func (s *Server) HandleStream(w http.ResponseWriter, r *http.Request) {
+	filter := streamFilter{}
+
+	if authorIDStr := r.URL.Query().Get("author_id"); authorIDStr != "" {
+		id, err := uuid.Parse(authorIDStr)
+		if err != nil {
+			s.RespondWithError(w, http.StatusBadRequest, "Invalid author_id", err)
+			return
+		}
+		filter.authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	if tagStr := r.URL.Query().Get("hashtag"); tagStr != "" {
+		filter.hashtag = chirptext.NormalizeHashtag(tagStr)
+		if filter.hashtag == "" {
+			s.RespondWithError(w, http.StatusBadRequest, "Invalid hashtag", nil)
+			return
+		}
+	}
+
+	var lastSeen *Cursor
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		cursor, err := DecodeCursor(lastEventID)
+		if err != nil {
+			s.RespondWithError(w, http.StatusBadRequest, "Invalid Last-Event-ID", err)
+			return
+		}
+		if s.now().Sub(cursor.CreatedAt) > maxReplayAge {
+			s.RespondWithError(w, http.StatusBadRequest, "Last-Event-ID is too old to resume from", nil)
+			return
+		}
+		lastSeen = &cursor
+	}
+
+	// The server's WriteTimeout would otherwise cut every stream off.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to start stream", err)
+		return
+	}
+
+	// Subscribe before replaying so nothing published in between is lost.
+	sub := s.chirps.Subscribe(streamBufferSize, filter.match)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	if lastSeen != nil {
+		replayed, err := s.replayChirps(r.Context(), w, filter, *lastSeen)
+		if err != nil {
+			s.logger.Printf("Unable to replay chirps: %v\n", err)
+			return
+		}
+		lastSeen = replayed
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(s.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case chirp, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			cursor := Cursor{CreatedAt: chirp.CreatedAt, ID: chirp.ID}
+			if lastSeen != nil && !cursorAfter(cursor, *lastSeen) {
+				continue
+			}
+			if err := writeChirpEvent(w, chirp); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// replayChirps writes every matching chirp created after the cursor and
+// returns the cursor of the last one it saw.
+func (s *Server) replayChirps(ctx context.Context, w http.ResponseWriter, filter streamFilter, after Cursor) (*Cursor, error) {
+	last := after
+	for {
+		chirps, err := s.db.GetChirpsPage(ctx, database.GetChirpsPageParams{
+			AuthorID:				filter.authorID,
+			AfterCreatedAt:	sql.NullTime{Time: last.CreatedAt, Valid: true},
+			AfterID:				uuid.NullUUID{UUID: last.ID, Valid: true},
+			PageLimit:			maxPageLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(chirps) == 0 {
+			return &last, nil
+		}
+
+		chirpResponses, err := s.ChirpResponses(ctx, uuid.NullUUID{}, chirps)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, chirp := range chirpResponses {
+			if !filter.match(chirp) {
+				continue
+			}
+			if err := writeChirpEvent(w, chirp); err != nil {
+				return nil, err
+			}
+		}
+
+		final := chirps[len(chirps)-1]
+		last = Cursor{CreatedAt: final.CreatedAt, ID: final.ID}
+		if len(chirps) < maxPageLimit {
+			return &last, nil
+		}
+	}
+}
+
+func writeChirpEvent(w http.ResponseWriter, chirp Chirp) error {
+	dat, err := json.Marshal(chirp)
+	if err != nil {
+		return err
+	}
+
+	id := EncodeCursor(Cursor{CreatedAt: chirp.CreatedAt, ID: chirp.ID})
+	_, err = fmt.Fprintf(w, "id: %s\nevent: chirp\ndata: %s\n\n", id, dat)
+	return err
+}
+
+// cursorAfter reports whether a sorts after b in (created_at, id) order.
+func cursorAfter(a, b Cursor) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return bytes.Compare(a.ID[:], b.ID[:]) > 0
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type streamEvent struct {
+	ID		string
+	Event	string
+	Data	string
+}
+
+func openStream(t *testing.T, ts *httptest.Server, query, lastEventID string) (*bufio.Reader, func()) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/stream"+query, nil)
+	if err != nil {
+		t.Fatalf("Failed to build stream request: %v", err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		resp.Body.Close()
+		t.Fatalf("Expected text/event-stream, got %q", ct)
+	}
+
+	return bufio.NewReader(resp.Body), func() { resp.Body.Close() }
+}
+
+// readEvent returns the next event, skipping comment lines such as
+// heartbeats unless they are the only thing in the frame.
+func readEvent(t *testing.T, reader *bufio.Reader) streamEvent {
+	t.Helper()
+
+	var ev streamEvent
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if ev != (streamEvent{}) {
+				return ev
+			}
+		case strings.HasPrefix(line, ":"):
+			ev.Event = "comment"
+		case strings.HasPrefix(line, "id: "):
+			ev.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			ev.Data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func decodeEventChirp(t *testing.T, ev streamEvent) Chirp {
+	t.Helper()
+	if ev.Event != "chirp" {
+		t.Fatalf("Expected chirp event, got %+v", ev)
+	}
+
+	var chirp Chirp
+	if err := json.Unmarshal([]byte(ev.Data), &chirp); err != nil {
+		t.Fatalf("Failed to decode event data %q: %v", ev.Data, err)
+	}
+	return chirp
+}
+
+func waitForSubscribers(t *testing.T, s *Server, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for s.chirps.Subscribers() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d stream subscribers, have %d", n, s.chirps.Subscribers())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHandleStream(t *testing.T) {
+	s := newTestServer(t)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+
+	all, closeAll := openStream(t, ts, "", "")
+	defer closeAll()
+	byBob, closeByBob := openStream(t, ts, "?author_id="+bob.ID.String(), "")
+	defer closeByBob()
+	tagged, closeTagged := openStream(t, ts, "?hashtag=Go", "")
+	defer closeTagged()
+
+	first := createChirp(t, s, alice, "hello #go")
+	second := createChirp(t, s, bob, "hi there")
+	rec := doRequest(t, s, http.MethodPost, "/api/chirps/"+first.ID.String()+"/rechirp", bob.bearer(), nil)
+	expectStatus(t, rec, http.StatusCreated)
+	var rechirp Chirp
+	decodeBody(t, rec, &rechirp)
+
+	if got := decodeEventChirp(t, readEvent(t, all)); got.ID != first.ID {
+		t.Fatalf("Expected first chirp %v, got %v", first.ID, got.ID)
+	}
+	if got := decodeEventChirp(t, readEvent(t, all)); got.ID != second.ID {
+		t.Fatalf("Expected second chirp %v, got %v", second.ID, got.ID)
+	}
+	live := decodeEventChirp(t, readEvent(t, all))
+	if live.ID != rechirp.ID || live.RechirpOf == nil || live.RechirpOf.ID != first.ID {
+		t.Fatalf("Expected rechirp %v of %v, got %+v", rechirp.ID, first.ID, live)
+	}
+	if got := decodeEventChirp(t, readEvent(t, byBob)); got.ID != second.ID {
+		t.Fatalf("Expected author filter to deliver %v, got %v", second.ID, got.ID)
+	}
+	if got := decodeEventChirp(t, readEvent(t, byBob)); got.ID != rechirp.ID {
+		t.Fatalf("Expected author filter to deliver rechirp %v, got %v", rechirp.ID, got.ID)
+	}
+
+	replay, closeReplay := openStream(t, ts, "", EncodeCursor(Cursor{CreatedAt: second.CreatedAt, ID: second.ID}))
+	defer closeReplay()
+	if got := decodeEventChirp(t, readEvent(t, replay)); !reflect.DeepEqual(got, live) {
+		t.Fatalf("Expected replayed rechirp to match the live event\nlive:   %+v\nreplay: %+v", live, got)
+	}
+	closeReplay()
+	if got := decodeEventChirp(t, readEvent(t, tagged)); got.ID != first.ID {
+		t.Fatalf("Expected hashtag filter to deliver %v, got %v", first.ID, got.ID)
+	}
+
+	closeAll()
+	closeByBob()
+	closeTagged()
+	waitForSubscribers(t, s, 0)
+}
+
+func TestHandleStream_Resume(t *testing.T) {
+	s := newTestServer(t)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+
+	first := createChirp(t, s, alice, "one")
+	second := createChirp(t, s, alice, "two")
+	third := createChirp(t, s, alice, "three")
+
+	stream, closeStream := openStream(t, ts, "", EncodeCursor(Cursor{CreatedAt: first.CreatedAt, ID: first.ID}))
+	defer closeStream()
+
+	for _, want := range []Chirp{second, third} {
+		if got := decodeEventChirp(t, readEvent(t, stream)); got.ID != want.ID {
+			t.Fatalf("Expected replayed chirp %v, got %v", want.ID, got.ID)
+		}
+	}
+
+	waitForSubscribers(t, s, 1)
+	fourth := createChirp(t, s, alice, "four")
+	ev := readEvent(t, stream)
+	if got := decodeEventChirp(t, ev); got.ID != fourth.ID {
+		t.Fatalf("Expected live chirp %v, got %v", fourth.ID, got.ID)
+	}
+	if ev.ID != EncodeCursor(Cursor{CreatedAt: fourth.CreatedAt, ID: fourth.ID}) {
+		t.Fatalf("Expected event id to be the chirp cursor, got %q", ev.ID)
+	}
+}
+
+func TestHandleStream_HeartbeatAndShutdown(t *testing.T) {
+	s := newTestServer(t)
+	s.heartbeatInterval = 10 * time.Millisecond
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	stream, closeStream := openStream(t, ts, "", "")
+	defer closeStream()
+
+	if ev := readEvent(t, stream); ev.Event != "comment" {
+		t.Fatalf("Expected heartbeat comment, got %+v", ev)
+	}
+
+	s.Close()
+	for {
+		if _, err := stream.ReadString('\n'); err != nil {
+			break
+		}
+	}
+}
+
+func TestHandleStream_Errors(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := doRequest(t, s, http.MethodGet, "/api/stream?author_id=not-a-uuid", "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/stream?hashtag=123", "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/stream", nil)
+	req.Header.Set("Last-Event-ID", "garbage")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/stream", nil)
+	req.Header.Set("Last-Event-ID", EncodeCursor(Cursor{CreatedAt: time.Now().Add(-maxReplayAge - time.Minute), ID: uuid.New()}))
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/database"
+	"net/http"
+)
+
+type Thread struct {
+	Ancestors		[]Chirp		`json:"ancestors"`
+	Chirp				Chirp			`json:"chirp"`
+	Replies			[]Chirp		`json:"replies"`
+}
+
+// HandleGetThread returns a chirp with the chain of chirps it replies to,
+// root first, and a page of its direct replies, oldest first.
+func (s *Server) HandleGetThread(w http.ResponseWriter, r *http.Request) {
+	viewerID, err := s.OptionalUserID(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+
+	page, err := ParsePage(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	chirp, err := s.db.GetChirp(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.RespondWithError(w, http.StatusNotFound, "Not Found", err)
+		return
+	}
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve chirp", err)
+		return
+	}
+
+	ancestors, err := s.db.GetChirpAncestors(r.Context(), chirpID)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve thread", err)
+		return
+	}
+
+	replies, err := s.db.GetRepliesPage(r.Context(), database.GetRepliesPageParams{
+		ChirpID:				chirpID,
+		AfterCreatedAt:	page.AfterCreatedAt(),
+		AfterID:				page.AfterID(),
+		PageLimit:			page.QueryLimit(),
+	})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve thread", err)
+		return
+	}
+
+	if len(replies) > page.Limit {
+		replies = replies[:page.Limit]
+		last := replies[len(replies)-1]
+		SetNextLink(w, r, page.Limit, Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	chirps := make([]database.Chirp, 0, len(ancestors)+1+len(replies))
+	chirps = append(chirps, ancestors...)
+	chirps = append(chirps, chirp)
+	chirps = append(chirps, replies...)
+
+	chirpResponses, err := s.ChirpResponses(r.Context(), viewerID, chirps)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve thread", err)
+		return
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, Thread{
+		Ancestors:	chirpResponses[:len(ancestors)],
+		Chirp:			chirpResponses[len(ancestors)],
+		Replies:		chirpResponses[len(ancestors)+1:],
+	})
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"net/http"
+	"testing"
+)
+
+func createReply(t *testing.T, s *Server, user loggedInUser, parent Chirp, body string) Chirp {
+	t.Helper()
+
+	rec := doRequest(t, s, http.MethodPost, "/api/chirps", user.bearer(), map[string]any{
+		"body":					body,
+		"in_reply_to":	parent.ID,
+	})
+	expectStatus(t, rec, http.StatusCreated)
+
+	var chirp Chirp
+	decodeBody(t, rec, &chirp)
+	return chirp
+}
+
+func TestHandleGetThread(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+
+	root := createChirp(t, s, alice, "root")
+	reply := createReply(t, s, bob, root, "reply")
+	if !reply.InReplyTo.Valid || reply.InReplyTo.UUID != root.ID {
+		t.Fatalf("Expected reply to %v, got %+v", root.ID, reply.InReplyTo)
+	}
+	createReply(t, s, alice, reply, "first")
+	createReply(t, s, bob, reply, "second")
+	createReply(t, s, alice, reply, "third")
+
+	rec := doRequest(t, s, http.MethodGet, "/api/chirps/"+reply.ID.String()+"/thread?limit=2", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var thread Thread
+	decodeBody(t, rec, &thread)
+	if len(thread.Ancestors) != 1 || thread.Ancestors[0].ID != root.ID {
+		t.Fatalf("Expected root as only ancestor, got %+v", thread.Ancestors)
+	}
+	if thread.Ancestors[0].ReplyCount != 1 {
+		t.Fatalf("Expected root to have 1 reply, got %d", thread.Ancestors[0].ReplyCount)
+	}
+	if thread.Chirp.ID != reply.ID || thread.Chirp.ReplyCount != 3 {
+		t.Fatalf("Expected reply with 3 replies, got %+v", thread.Chirp)
+	}
+	if len(thread.Replies) != 2 || thread.Replies[0].Body != "first" {
+		t.Fatalf("Expected first page of 2 replies, got %+v", thread.Replies)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, nextLink(t, rec.Header().Get("Link")), "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &thread)
+	if len(thread.Replies) != 1 || thread.Replies[0].Body != "third" {
+		t.Fatalf("Expected last reply on second page, got %+v", thread.Replies)
+	}
+
+	rec = doRequest(t, s, http.MethodDelete, "/api/chirps/"+root.ID.String(), alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps/"+reply.ID.String()+"/thread", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &thread)
+	if len(thread.Ancestors) != 0 || thread.Chirp.InReplyTo.Valid {
+		t.Fatalf("Expected reply detached from deleted root, got %+v", thread)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/chirps/"+uuid.NewString()+"/thread", "", nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/chirps", bob.bearer(), map[string]any{
+		"body":					"orphan",
+		"in_reply_to":	uuid.NewString(),
+	})
+	expectStatus(t, rec, http.StatusBadRequest)
+}
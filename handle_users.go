@@ -1,10 +1,16 @@
 package main
 
 import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/chirptext"
 	"github.com/voylento/chirpy/internal/database"
 	"github.com/voylento/chirpy/internal/auth"
+	"github.com/voylento/chirpy/internal/store"
 	"net/http"
 	"time"
 )
@@ -14,65 +20,374 @@ type User struct {
 	CreatedAt		time.Time		`json:"created_at"`
 	UpdatedAt		time.Time		`json:"updated_at"`
 	Email				string			`json:"email"`
+	IsChirpyRed	bool				`json:"is_chirpy_red"`
+	Role				string			`json:"role"`
+	Username		string			`json:"username"`
+	DisplayName	string			`json:"display_name"`
+	Bio					string			`json:"bio"`
+	Location		string			`json:"location"`
+	Website			string			`json:"website"`
+	AvatarURL		string			`json:"avatar_url"`
 }
 
-func HandleCreateUser(w http.ResponseWriter, req *http.Request) {
+func NewUser(user database.User) User {
+	return User{
+		ID:						user.ID,
+		CreatedAt:		user.CreatedAt,
+		UpdatedAt:		user.UpdatedAt,
+		Email:				user.Email,
+		IsChirpyRed:	user.IsChirpyRed,
+		Role:					user.Role,
+		Username:			user.Username,
+		DisplayName:	user.DisplayName,
+		Bio:					user.Bio,
+		Location:			user.Location,
+		Website:			user.Website,
+		AvatarURL:		user.AvatarURL,
+	}
+}
+
+// PublicUser is the part of a user anyone may see.
+type PublicUser struct {
+	ID					uuid.UUID		`json:"id"`
+	CreatedAt		time.Time		`json:"created_at"`
+	Username		string			`json:"username"`
+	IsChirpyRed	bool				`json:"is_chirpy_red"`
+	DisplayName	string			`json:"display_name"`
+	Bio					string			`json:"bio"`
+	Location		string			`json:"location"`
+	Website			string			`json:"website"`
+	AvatarURL		string			`json:"avatar_url"`
+}
+
+func NewPublicUser(user database.User) PublicUser {
+	return PublicUser{
+		ID:						user.ID,
+		CreatedAt:		user.CreatedAt,
+		Username:			user.Username,
+		IsChirpyRed:	user.IsChirpyRed,
+		DisplayName:	user.DisplayName,
+		Bio:					user.Bio,
+		Location:			user.Location,
+		Website:			user.Website,
+		AvatarURL:		user.AvatarURL,
+	}
+}
+
+// Author is the compact user embedded in every chirp response.
+type Author struct {
+	ID					uuid.UUID		`json:"id"`
+	Username		string			`json:"username"`
+	DisplayName	string			`json:"display_name"`
+	AvatarURL		string			`json:"avatar_url"`
+	IsChirpyRed	bool				`json:"is_chirpy_red"`
+}
+
+func NewAuthor(user database.User) Author {
+	return Author{
+		ID:						user.ID,
+		Username:			user.Username,
+		DisplayName:	user.DisplayName,
+		AvatarURL:		user.AvatarURL,
+		IsChirpyRed:	user.IsChirpyRed,
+	}
+}
+
+// DefaultUsername is given to accounts created without choosing a username.
+func DefaultUsername() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "user_" + hex.EncodeToString(b), nil
+}
+
+func (s *Server) HandleCreateUser(w http.ResponseWriter, req *http.Request) {
 	type parameters struct {
 		Email 		string `json:"email"`
 		Password	string `json:"password"`
+		Username	string `json:"username"`
 	}
 
 	decoder := json.NewDecoder(req.Body)
 	params := parameters{}
 	err := decoder.Decode(&params)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Couldn't decode user parameters", err)
+		s.RespondWithError(w, http.StatusBadRequest, "Couldn't decode user parameters", err)
 		return
 	}
 
 	pwd_hash, err := auth.HashPassword(params.Password)
 	if err != nil {
-		RespondWithError(w, http.StatusBadRequest, "Password does not meet minimum requirements", err)
+		s.RespondWithError(w, http.StatusBadRequest, "Password does not meet minimum requirements", err)
+		return
+	}
+
+	username := chirptext.NormalizeUsername(params.Username)
+	if params.Username == "" {
+		username, err = DefaultUsername()
+		if err != nil {
+			s.RespondWithError(w, http.StatusInternalServerError, "Create User Failed", err)
+			return
+		}
+	} else if username == "" {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid username", nil)
 		return
 	}
 
 	userParams := database.CreateUserParams{
 		Email:						params.Email,
 		HashedPassword: 	pwd_hash,
+		Username:					username,
 	}
 
-	user, err := config.db.CreateUser(req.Context(), userParams)
+	user, err := s.db.CreateUser(req.Context(), userParams)
+	if store.IsUniqueViolation(err) {
+		s.RespondWithError(w, http.StatusConflict, "Email or username already in use", err)
+		return
+	}
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Create User Failed", err)
+		s.RespondWithError(w, http.StatusInternalServerError, "Create User Failed", err)
 		return
 	}
 
-	RespondWithJSON(w, http.StatusCreated, User{ 
-			ID: 				user.ID,
-			CreatedAt:	user.CreatedAt,
-			UpdatedAt: 	user.UpdatedAt,
-			Email:			user.Email,
-		},
-	)
+	s.RespondWithJSON(w, http.StatusCreated, NewUser(user))
 }
 
-func HandleGetUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := config.db.GetAllUsers(r.Context())
+// HandleGetUsers lists every account in full. It is mounted under /admin.
+func (s *Server) HandleGetUsers(w http.ResponseWriter, r *http.Request) {
+	page, err := ParsePage(r)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Count not retrieve users", err)
+		s.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
+	users, err := s.db.GetUsersPage(r.Context(), database.GetUsersPageParams{
+		AfterCreatedAt:	page.AfterCreatedAt(),
+		AfterID:				page.AfterID(),
+		PageLimit:			page.QueryLimit(),
+	})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Count not retrieve users", err)
+		return
+	}
+
+	if len(users) > page.Limit {
+		users = users[:page.Limit]
+		last := users[len(users)-1]
+		SetNextLink(w, r, page.Limit, Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
 	userResponses := make([]User, len(users))
 	for i, user := range users {
-		userResponses[i] = User{
-			ID:        user.ID,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-			Email:     user.Email,
+		userResponses[i] = NewUser(user)
+	}
+	s.RespondWithJSON(w, http.StatusOK, userResponses)
+}
+
+func (s *Server) HandleGetUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+
+	user, err := s.db.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve user", err)
+		return
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, NewPublicUser(user))
+}
+
+func (s *Server) HandleGetUserByUsername(w http.ResponseWriter, r *http.Request) {
+	username := chirptext.NormalizeUsername(r.PathValue("username"))
+	if username == "" {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid username", nil)
+		return
+	}
+
+	user, err := s.db.GetUserByUsername(r.Context(), username)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to retrieve user", err)
+		return
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, NewPublicUser(user))
+}
+
+// HandleSearchUsers finds users whose username starts with ?q=.
+func (s *Server) HandleSearchUsers(w http.ResponseWriter, r *http.Request) {
+	prefix := chirptext.NormalizeUsernamePrefix(r.URL.Query().Get("q"))
+	if prefix == "" {
+		s.RespondWithError(w, http.StatusBadRequest, "Invalid q, must be the start of a username", nil)
+		return
+	}
+
+	page, err := ParsePage(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	users, err := s.db.SearchUsersPage(r.Context(), database.SearchUsersPageParams{
+		Prefix:					prefix,
+		AfterCreatedAt:	page.AfterCreatedAt(),
+		AfterID:				page.AfterID(),
+		PageLimit:			page.QueryLimit(),
+	})
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to search users", err)
+		return
+	}
+
+	if len(users) > page.Limit {
+		users = users[:page.Limit]
+		last := users[len(users)-1]
+		SetNextLink(w, r, page.Limit, Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	userResponses := make([]PublicUser, len(users))
+	for i, user := range users {
+		userResponses[i] = NewPublicUser(user)
+	}
+	s.RespondWithJSON(w, http.StatusOK, userResponses)
+}
+
+func (s *Server) HandleUpdateUser(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Email 		string `json:"email"`
+		Password	string `json:"password"`
+		Username	string `json:"username"`
+	}
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(token, s.secret)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Couldn't decode user parameters", err)
+		return
+	}
+
+	if params.Email == "" && params.Password == "" && params.Username == "" {
+		s.RespondWithError(w, http.StatusBadRequest, "Nothing to update, expected email, password or username", nil)
+		return
+	}
+
+	current, err := s.db.GetUserByID(req.Context(), userID)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Update User Failed", err)
+		return
+	}
+
+	// Fields left out keep their current values.
+	email := current.Email
+	if params.Email != "" {
+		email = params.Email
+	}
+
+	pwd_hash := current.HashedPassword
+	if params.Password != "" {
+		pwd_hash, err = auth.HashPassword(params.Password)
+		if err != nil {
+			s.RespondWithError(w, http.StatusBadRequest, "Password does not meet minimum requirements", err)
+			return
 		}
 	}
-	RespondWithJSON(w, http.StatusOK, userResponses)
+
+	username := current.Username
+	if params.Username != "" {
+		username = chirptext.NormalizeUsername(params.Username)
+		if username == "" {
+			s.RespondWithError(w, http.StatusBadRequest, "Invalid username", nil)
+			return
+		}
+	}
+
+	user, err := s.db.UpdateUser(req.Context(), database.UpdateUserParams{
+		ID:								userID,
+		Email:						email,
+		HashedPassword:		pwd_hash,
+		Username:					username,
+	})
+	if store.IsUniqueViolation(err) {
+		s.RespondWithError(w, http.StatusConflict, "Email or username already in use", err)
+		return
+	}
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Update User Failed", err)
+		return
+	}
+
+	// A new password signs out every other session.
+	if params.Password != "" {
+		err = s.db.RevokeUserRefreshTokens(req.Context(), userID)
+		if err != nil {
+			s.RespondWithError(w, http.StatusInternalServerError, "Unable to revoke refresh tokens", err)
+			return
+		}
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, NewUser(user))
 }
 
+// HandleUpdateProfile serves PATCH /api/users/me. Only the fields present in
+// the body change.
+func (s *Server) HandleUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.AuthenticatedUserID(r)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	var profile Profile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Couldn't decode profile parameters", err)
+		return
+	}
 
+	current, err := s.db.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Update Profile Failed", err)
+		return
+	}
+
+	params, err := profile.Apply(current)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	user, err := s.db.UpdateUserProfile(r.Context(), params)
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Update Profile Failed", err)
+		return
+	}
+
+	s.RespondWithJSON(w, http.StatusOK, NewUser(user))
+}
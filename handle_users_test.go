@@ -0,0 +1,462 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHandleCreateUser(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := doRequest(t, s, http.MethodPost, "/api/users", "", map[string]string{
+		"email":		"a@example.com",
+		"password":	"password1",
+	})
+	expectStatus(t, rec, http.StatusCreated)
+
+	var user User
+	decodeBody(t, rec, &user)
+	if user.Email != "a@example.com" {
+		t.Fatalf("Expected email a@example.com, got %s", user.Email)
+	}
+	if user.IsChirpyRed {
+		t.Fatalf("Expected new user not to be Chirpy Red")
+	}
+	if strings.Contains(rec.Body.String(), "password") {
+		t.Fatalf("Response leaked password data: %s", rec.Body.String())
+	}
+}
+
+func TestHandleCreateUser_Errors(t *testing.T) {
+	tests := []struct {
+		name		string
+		body		any
+		status	int
+	}{
+		{
+			name:		"Malformed JSON",
+			body:		`{"email":`,
+			status:	http.StatusBadRequest,
+		},
+		{
+			name:		"Password too long for bcrypt",
+			body:		map[string]string{"email": "a@example.com", "password": strings.Repeat("x", 100)},
+			status:	http.StatusBadRequest,
+		},
+		{
+			name:		"Invalid username",
+			body:		map[string]string{"email": "a@example.com", "password": "password1", "username": "no spaces"},
+			status:	http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			rec := doRequest(t, s, http.MethodPost, "/api/users", "", tt.body)
+			expectStatus(t, rec, tt.status)
+		})
+	}
+}
+
+func TestHandleCreateUser_Username(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := doRequest(t, s, http.MethodPost, "/api/users", "", map[string]string{
+		"email":		"a@example.com",
+		"password":	"password1",
+		"username":	"@Alice",
+	})
+	expectStatus(t, rec, http.StatusCreated)
+
+	var user User
+	decodeBody(t, rec, &user)
+	if user.Username != "alice" {
+		t.Fatalf("Expected normalized username alice, got %q", user.Username)
+	}
+
+	rec = doRequest(t, s, http.MethodPost, "/api/users", "", map[string]string{
+		"email":		"b@example.com",
+		"password":	"password1",
+		"username":	"ALICE",
+	})
+	expectStatus(t, rec, http.StatusConflict)
+
+	other := createAndLogin(t, s, "c@example.com", "password1")
+	if !strings.HasPrefix(other.Username, "user_") {
+		t.Fatalf("Expected a default username, got %q", other.Username)
+	}
+
+	rec = doRequest(t, s, http.MethodPut, "/api/users", other.bearer(), map[string]string{
+		"email":		"c@example.com",
+		"password":	"password1",
+		"username":	"alice",
+	})
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = doRequest(t, s, http.MethodPut, "/api/users", other.bearer(), map[string]string{
+		"email":		"c@example.com",
+		"password":	"password1",
+		"username":	"carol",
+	})
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &user)
+	if user.Username != "carol" {
+		t.Fatalf("Expected username carol, got %q", user.Username)
+	}
+}
+
+func TestHandleUpdateUser(t *testing.T) {
+	s := newTestServer(t)
+	user := createAndLogin(t, s, "a@example.com", "password1")
+
+	rec := doRequest(t, s, http.MethodPut, "/api/users", user.bearer(), map[string]string{
+		"email":		"b@example.com",
+		"password":	"password2",
+	})
+	expectStatus(t, rec, http.StatusOK)
+
+	var updated User
+	decodeBody(t, rec, &updated)
+	if updated.ID != user.ID || updated.Email != "b@example.com" {
+		t.Fatalf("Expected user %v with email b@example.com, got %v with %s", user.ID, updated.ID, updated.Email)
+	}
+
+	rec = doRequest(t, s, http.MethodPost, "/api/login", "", map[string]string{
+		"email":		"b@example.com",
+		"password":	"password2",
+	})
+	expectStatus(t, rec, http.StatusOK)
+}
+
+func TestHandleUpdateUser_Partial(t *testing.T) {
+	s := newTestServer(t)
+	user := createAndLogin(t, s, "a@example.com", "password1")
+
+	rec := doRequest(t, s, http.MethodPut, "/api/users", user.bearer(), map[string]string{
+		"email":	"b@example.com",
+	})
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/login", "", map[string]string{
+		"email":		"b@example.com",
+		"password":	"",
+	})
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/login", "", map[string]string{
+		"email":		"b@example.com",
+		"password":	"password1",
+	})
+	expectStatus(t, rec, http.StatusOK)
+
+	// The refresh token survives an email change but not a password change.
+	rec = doRequest(t, s, http.MethodPost, "/api/refresh", "Bearer "+user.RefreshToken, nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = doRequest(t, s, http.MethodPut, "/api/users", user.bearer(), map[string]string{
+		"password":	"password2",
+	})
+	expectStatus(t, rec, http.StatusOK)
+
+	var updated User
+	decodeBody(t, rec, &updated)
+	if updated.Email != "b@example.com" {
+		t.Fatalf("Expected the email to be kept, got %q", updated.Email)
+	}
+
+	rec = doRequest(t, s, http.MethodPost, "/api/login", "", map[string]string{
+		"email":		"b@example.com",
+		"password":	"password2",
+	})
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/refresh", "Bearer "+user.RefreshToken, nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+}
+
+func TestHandleUpdateUser_Errors(t *testing.T) {
+	s := newTestServer(t)
+	user := createAndLogin(t, s, "a@example.com", "password1")
+
+	tests := []struct {
+		name		string
+		auth		string
+		body		any
+		status	int
+	}{
+		{
+			name:		"Missing token",
+			auth:		"",
+			body:		map[string]string{"email": "b@example.com", "password": "password2"},
+			status:	http.StatusUnauthorized,
+		},
+		{
+			name:		"Invalid token",
+			auth:		"Bearer not.a.jwt",
+			body:		map[string]string{"email": "b@example.com", "password": "password2"},
+			status:	http.StatusUnauthorized,
+		},
+		{
+			name:		"Malformed JSON",
+			auth:		user.bearer(),
+			body:		`{"email":`,
+			status:	http.StatusBadRequest,
+		},
+		{
+			name:		"Nothing to update",
+			auth:		user.bearer(),
+			body:		map[string]string{},
+			status:	http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, s, http.MethodPut, "/api/users", tt.auth, tt.body)
+			expectStatus(t, rec, tt.status)
+		})
+	}
+}
+
+func TestHandleGetUsers_Pagination(t *testing.T) {
+	s := newTestServer(t)
+	admin := createAndLogin(t, s, "admin@example.com", "password1", asAdmin())
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		createAndLogin(t, s, email, "password1")
+	}
+
+	rec := doRequest(t, s, http.MethodGet, "/api/users", "", nil)
+	expectStatus(t, rec, http.StatusMethodNotAllowed)
+
+	rec = doRequest(t, s, http.MethodGet, "/admin/users?limit=2", admin.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var first []User
+	decodeBody(t, rec, &first)
+	if len(first) != 2 {
+		t.Fatalf("Expected 2 users on first page, got %d", len(first))
+	}
+	if first[0].Email != "admin@example.com" {
+		t.Fatalf("Expected admin listing to include emails, got %+v", first[0])
+	}
+
+	next := nextLink(t, rec.Header().Get("Link"))
+	rec = doRequest(t, s, http.MethodGet, next, admin.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	var second []User
+	decodeBody(t, rec, &second)
+	if len(second) != 1 {
+		t.Fatalf("Expected 1 user on second page, got %d", len(second))
+	}
+	if rec.Header().Get("Link") != "" {
+		t.Fatalf("Expected no Link header on last page, got %s", rec.Header().Get("Link"))
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/admin/users?cursor=garbage", admin.bearer(), nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = doRequest(t, s, http.MethodGet, "/admin/users?limit=0", admin.bearer(), nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestHandleGetUser(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1", withUsername("alice"))
+
+	for _, path := range []string{
+		"/api/users/" + alice.ID.String(),
+		"/api/users/by-username/Alice",
+		"/api/users/by-username/@alice",
+	} {
+		rec := doRequest(t, s, http.MethodGet, path, "", nil)
+		expectStatus(t, rec, http.StatusOK)
+
+		if strings.Contains(rec.Body.String(), "a@example.com") {
+			t.Fatalf("Expected %s to hide the email, got %s", path, rec.Body.String())
+		}
+		var got PublicUser
+		decodeBody(t, rec, &got)
+		if got.ID != alice.ID || got.Username != "alice" {
+			t.Fatalf("Expected alice from %s, got %+v", path, got)
+		}
+	}
+
+	rec := doRequest(t, s, http.MethodGet, "/api/users/"+uuid.NewString(), "", nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/users/not-a-uuid", "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/users/by-username/nobody", "", nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/users/by-username/no", "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/users/"+alice.ID.String()+"/likes", "", nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/users/by-usernme/alice", "", nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	// A username that collides with a listing name is still a username.
+	rec = doRequest(t, s, http.MethodGet, "/api/users/by-username/followers", "", nil)
+	expectStatus(t, rec, http.StatusNotFound)
+	if !strings.Contains(rec.Body.String(), "User not found") {
+		t.Fatalf("Expected a username lookup, got %s", rec.Body.String())
+	}
+}
+
+func TestHandleSearchUsers(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1", withUsername("alice"))
+	alfred := createAndLogin(t, s, "b@example.com", "password1", withUsername("al_fred"))
+	createAndLogin(t, s, "c@example.com", "password1", withUsername("albert"))
+	createAndLogin(t, s, "d@example.com", "password1", withUsername("bob"))
+
+	rec := doRequest(t, s, http.MethodGet, "/api/users/search?q=AL&limit=2", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if strings.Contains(rec.Body.String(), "@example.com") {
+		t.Fatalf("Expected search results to hide emails, got %s", rec.Body.String())
+	}
+
+	var page []PublicUser
+	decodeBody(t, rec, &page)
+	if len(page) != 2 || page[0].ID != alice.ID || page[1].ID != alfred.ID {
+		t.Fatalf("Expected alice and al_fred on the first page, got %+v", page)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, nextLink(t, rec.Header().Get("Link")), "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &page)
+	if len(page) != 1 || page[0].Username != "albert" {
+		t.Fatalf("Expected albert on the second page, got %+v", page)
+	}
+
+	// An underscore is a literal, not a LIKE wildcard.
+	rec = doRequest(t, s, http.MethodGet, "/api/users/search?q=al_", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &page)
+	if len(page) != 1 || page[0].ID != alfred.ID {
+		t.Fatalf("Expected only al_fred, got %+v", page)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/users/search?q=", "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = doRequest(t, s, http.MethodGet, "/api/users/search?q=a%25", "", nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestHandleUpdateProfile(t *testing.T) {
+	s := newTestServer(t)
+	alice := createAndLogin(t, s, "a@example.com", "password1", withUsername("alice"))
+
+	rec := doRequest(t, s, http.MethodPatch, "/api/users/me", alice.bearer(), map[string]string{
+		"display_name":	"  Alice Liddell ",
+		"bio":					"Down the rabbit hole",
+		"website":			"https://example.com/alice",
+	})
+	expectStatus(t, rec, http.StatusOK)
+
+	var user User
+	decodeBody(t, rec, &user)
+	if user.DisplayName != "Alice Liddell" || user.Bio != "Down the rabbit hole" || user.Website != "https://example.com/alice" {
+		t.Fatalf("Expected profile to be updated, got %+v", user)
+	}
+
+	// Omitted fields are kept; an empty string clears.
+	rec = doRequest(t, s, http.MethodPatch, "/api/users/me", alice.bearer(), map[string]string{
+		"location":	"Oxford",
+		"bio":			"",
+	})
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &user)
+	if user.DisplayName != "Alice Liddell" || user.Location != "Oxford" || user.Bio != "" {
+		t.Fatalf("Expected a partial update, got %+v", user)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/api/users/by-username/alice", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var public PublicUser
+	decodeBody(t, rec, &public)
+	if public.DisplayName != "Alice Liddell" || public.Location != "Oxford" {
+		t.Fatalf("Expected public profile fields, got %+v", public)
+	}
+}
+
+func TestHandleUpdateProfile_Errors(t *testing.T) {
+	s := newTestServer(t)
+	user := createAndLogin(t, s, "a@example.com", "password1")
+
+	tests := []struct {
+		name		string
+		auth		string
+		body		any
+		status	int
+	}{
+		{
+			name:		"Missing token",
+			auth:		"",
+			body:		map[string]string{"bio": "hello"},
+			status:	http.StatusUnauthorized,
+		},
+		{
+			name:		"Malformed JSON",
+			auth:		user.bearer(),
+			body:		`{"bio":`,
+			status:	http.StatusBadRequest,
+		},
+		{
+			name:		"Display name too long",
+			auth:		user.bearer(),
+			body:		map[string]string{"display_name": strings.Repeat("a", 51)},
+			status:	http.StatusBadRequest,
+		},
+		{
+			name:		"Bio too long",
+			auth:		user.bearer(),
+			body:		map[string]string{"bio": strings.Repeat("a", 161)},
+			status:	http.StatusBadRequest,
+		},
+		{
+			name:		"Control characters",
+			auth:		user.bearer(),
+			body:		map[string]string{"location": "Ox\tford"},
+			status:	http.StatusBadRequest,
+		},
+		{
+			name:		"Website not http",
+			auth:		user.bearer(),
+			body:		map[string]string{"website": "javascript:alert(1)"},
+			status:	http.StatusBadRequest,
+		},
+		{
+			name:		"Avatar without host",
+			auth:		user.bearer(),
+			body:		map[string]string{"avatar_url": "https://"},
+			status:	http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(t, s, http.MethodPatch, "/api/users/me", tt.auth, tt.body)
+			expectStatus(t, rec, tt.status)
+		})
+	}
+}
+
+func nextLink(t *testing.T, header string) string {
+	t.Helper()
+	start := strings.Index(header, "<")
+	end := strings.Index(header, ">")
+	if start < 0 || end < start || !strings.Contains(header, `rel="next"`) {
+		t.Fatalf("Expected next Link header, got %q", header)
+	}
+	return header[start+1 : end]
+}
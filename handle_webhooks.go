@@ -0,0 +1,53 @@
+package main
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/auth"
+	"net/http"
+)
+
+const polkaEventUserUpgraded = "user.upgraded"
+
+func (s *Server) HandlePolkaWebhook(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Event		string	`json:"event"`
+		Data		struct {
+			UserID	uuid.UUID	`json:"user_id"`
+		}	`json:"data"`
+	}
+
+	apiKey, err := auth.GetAPIKey(req.Header)
+	if err != nil || subtle.ConstantTimeCompare([]byte(apiKey), []byte(s.polkaKey)) != 1 {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		s.RespondWithError(w, http.StatusBadRequest, "Couldn't decode webhook parameters", err)
+		return
+	}
+
+	if params.Event != polkaEventUserUpgraded {
+		RespondWithStatusCode(w, http.StatusNoContent)
+		return
+	}
+
+	_, err = s.db.UpgradeUserToChirpyRed(req.Context(), params.Data.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		s.RespondWithError(w, http.StatusInternalServerError, "Unable to upgrade user", err)
+		return
+	}
+
+	RespondWithStatusCode(w, http.StatusNoContent)
+}
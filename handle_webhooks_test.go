@@ -0,0 +1,50 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"net/http"
+	"testing"
+)
+
+func TestHandlePolkaWebhook(t *testing.T) {
+	s := newTestServer(t)
+	user := createAndLogin(t, s, "a@example.com", "password1")
+	apiKey := "ApiKey " + testPolkaKey
+
+	upgrade := func(userID uuid.UUID) map[string]any {
+		return map[string]any{
+			"event":	"user.upgraded",
+			"data":		map[string]string{"user_id": userID.String()},
+		}
+	}
+
+	rec := doRequest(t, s, http.MethodPost, "/api/polka/webhooks", "", upgrade(user.ID))
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/polka/webhooks", "ApiKey wrong", upgrade(user.ID))
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/polka/webhooks", apiKey, map[string]any{
+		"event":	"user.payment_failed",
+		"data":		map[string]string{"user_id": user.ID.String()},
+	})
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/polka/webhooks", apiKey, upgrade(uuid.New()))
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/polka/webhooks", apiKey, upgrade(user.ID))
+	expectStatus(t, rec, http.StatusNoContent)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/login", "", map[string]string{
+		"email":		"a@example.com",
+		"password":	"password1",
+	})
+	expectStatus(t, rec, http.StatusOK)
+
+	var loggedIn loggedInUser
+	decodeBody(t, rec, &loggedIn)
+	if !loggedIn.IsChirpyRed {
+		t.Fatalf("Expected user to be Chirpy Red after upgrade")
+	}
+}
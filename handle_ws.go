@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/voylento/chirpy/internal/auth"
+	"github.com/voylento/chirpy/internal/database"
+	"maps"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	maxWebSocketsPerUser	= 5
+	wsSendBuffer					= 64
+	wsWriteWait						= 10 * time.Second
+	wsMaxMessageSize			= 4096
+)
+
+// wsUpgrader keeps gorilla's default origin check, so a page on another
+// site cannot open a socket with a token it has got hold of.
+var wsUpgrader = websocket.Upgrader{}
+
+const (
+	channelTimeline				= "timeline"
+	channelNotifications	= "notifications"
+)
+
+// wsRequest is a message from the client, e.g.
+// {"type": "subscribe", "channel": "timeline"}.
+type wsRequest struct {
+	Type		string	`json:"type"`
+	Channel	string	`json:"channel"`
+}
+
+type wsMessage struct {
+	Type					string				`json:"type"`
+	Channel				string				`json:"channel,omitempty"`
+	Chirp					*Chirp				`json:"chirp,omitempty"`
+	Notification	*Notification	`json:"notification,omitempty"`
+	Error					string				`json:"error,omitempty"`
+}
+
+// wsSession is one authenticated websocket. It holds both subscriptions for
+// its whole life and the channel flags decide what gets through, so the
+// broker's buffer is the connection's only send queue. The connection allows
+// one writer at a time and both the read and write loops send, hence writeMu.
+//
+// followees is loaded once per connection and then kept current by
+// followChanged, so the timeline filter never has to ask the store. It is
+// replaced rather than mutated, letting the broker read it without a lock.
+type wsSession struct {
+	server					*Server
+	conn						*websocket.Conn
+	userID					uuid.UUID
+	timeline				atomic.Bool
+	notifications		atomic.Bool
+	writeMu					sync.Mutex
+	followMu				sync.Mutex
+	followees				atomic.Pointer[map[uuid.UUID]bool]
+}
+
+// HandleWebSocket upgrades to a websocket that pushes the user's home
+// timeline and notifications once subscribed. Browsers cannot set headers
+// on a websocket request, so the access token may also be passed as
+// ?access_token=.
+func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		token = r.URL.Query().Get("access_token")
+	}
+
+	userID, err := auth.ValidateJWT(token, s.secret)
+	if err != nil {
+		s.RespondWithError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	if !s.acquireWebSocket(userID) {
+		s.RespondWithError(w, http.StatusTooManyRequests, "Too many open connections", nil)
+		return
+	}
+	defer s.releaseWebSocket(userID)
+
+	// Upgrade has already written an error response when it fails.
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.logger.Printf("Websocket upgrade failed: %v\n", err)
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(wsMaxMessageSize)
+
+	session := &wsSession{
+		server:	s,
+		conn:		conn,
+		userID:	userID,
+	}
+	s.trackWebSocket(session)
+	defer s.untrackWebSocket(session)
+	session.run(r.Context())
+}
+
+func (s *Server) acquireWebSocket(userID uuid.UUID) bool {
+	s.wsMu.Lock()
+	defer s.wsMu.Unlock()
+
+	if s.wsConns[userID] >= maxWebSocketsPerUser {
+		return false
+	}
+	s.wsConns[userID]++
+	return true
+}
+
+func (s *Server) trackWebSocket(ws *wsSession) {
+	s.wsMu.Lock()
+	defer s.wsMu.Unlock()
+
+	if s.wsSessions[ws.userID] == nil {
+		s.wsSessions[ws.userID] = make(map[*wsSession]bool)
+	}
+	s.wsSessions[ws.userID][ws] = true
+}
+
+func (s *Server) untrackWebSocket(ws *wsSession) {
+	s.wsMu.Lock()
+	defer s.wsMu.Unlock()
+
+	delete(s.wsSessions[ws.userID], ws)
+	if len(s.wsSessions[ws.userID]) == 0 {
+		delete(s.wsSessions, ws.userID)
+	}
+}
+
+// followChanged updates the followee sets of followerID's open websockets.
+// It runs after the follows table has changed, so a socket still loading its
+// set either sees the change in the store or gets it here afterwards.
+func (s *Server) followChanged(followerID, followeeID uuid.UUID, following bool) {
+	s.wsMu.Lock()
+	sessions := make([]*wsSession, 0, len(s.wsSessions[followerID]))
+	for ws := range s.wsSessions[followerID] {
+		sessions = append(sessions, ws)
+	}
+	s.wsMu.Unlock()
+
+	for _, ws := range sessions {
+		ws.setFollowing(followeeID, following)
+	}
+}
+
+func (s *Server) releaseWebSocket(userID uuid.UUID) {
+	s.wsMu.Lock()
+	defer s.wsMu.Unlock()
+
+	s.wsConns[userID]--
+	if s.wsConns[userID] <= 0 {
+		delete(s.wsConns, userID)
+	}
+}
+
+// run writes until the client goes away, stops answering pings, falls too
+// far behind, or the server shuts down.
+func (ws *wsSession) run(ctx context.Context) {
+	if err := ws.loadFollowees(ctx); err != nil {
+		ws.server.logger.Printf("Unable to load followees for websocket: %v\n", err)
+		ws.close(websocket.CloseInternalServerErr, "Unable to load timeline")
+		return
+	}
+
+	chirps := ws.server.chirps.Subscribe(wsSendBuffer, func(chirp Chirp) bool {
+		return ws.timeline.Load() && (*ws.followees.Load())[chirp.UserID]
+	})
+	defer chirps.Close()
+
+	notifications := ws.server.notifications.Subscribe(wsSendBuffer, func(n database.Notification) bool {
+		return ws.notifications.Load() && n.UserID == ws.userID
+	})
+	defer notifications.Close()
+
+	pongWait := 2 * ws.server.heartbeatInterval
+	ws.conn.SetReadDeadline(time.Now().Add(pongWait))
+	ws.conn.SetPongHandler(func(string) error {
+		return ws.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	readDone := make(chan error, 1)
+	go func() {
+		readDone <- ws.readLoop(pongWait)
+	}()
+
+	ping := time.NewTicker(ws.server.heartbeatInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case err := <-readDone:
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) {
+				ws.server.logger.Printf("Websocket for %v closed: %v\n", ws.userID, err)
+			}
+			return
+		case chirp, ok := <-chirps.C:
+			if !ok {
+				ws.close(websocket.CloseTryAgainLater, "Subscription closed, reconnect")
+				return
+			}
+			if err := ws.send(wsMessage{Type: "chirp", Chirp: &chirp}); err != nil {
+				return
+			}
+		case n, ok := <-notifications.C:
+			if !ok {
+				ws.close(websocket.CloseTryAgainLater, "Subscription closed, reconnect")
+				return
+			}
+			notification := NewNotification(n)
+			if err := ws.send(wsMessage{Type: "notification", Notification: &notification}); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := ws.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (ws *wsSession) loadFollowees(ctx context.Context) error {
+	ws.followMu.Lock()
+	defer ws.followMu.Unlock()
+
+	ids, err := ws.server.db.GetFolloweeIDs(ctx, ws.userID)
+	if err != nil {
+		return err
+	}
+
+	followees := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		followees[id] = true
+	}
+	ws.followees.Store(&followees)
+	return nil
+}
+
+func (ws *wsSession) setFollowing(followeeID uuid.UUID, following bool) {
+	ws.followMu.Lock()
+	defer ws.followMu.Unlock()
+
+	current := ws.followees.Load()
+	if current == nil {
+		// Not loaded yet; the load will read the change from the store.
+		return
+	}
+
+	followees := maps.Clone(*current)
+	if following {
+		followees[followeeID] = true
+	} else {
+		delete(followees, followeeID)
+	}
+	ws.followees.Store(&followees)
+}
+
+func (ws *wsSession) readLoop(pongWait time.Duration) error {
+	for {
+		messageType, data, err := ws.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		ws.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		if messageType != websocket.TextMessage {
+			ws.sendError("Expected a JSON text message")
+			continue
+		}
+
+		var req wsRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			ws.sendError("Unable to decode message")
+			continue
+		}
+
+		var flag *atomic.Bool
+		switch req.Channel {
+		case channelTimeline:
+			flag = &ws.timeline
+		case channelNotifications:
+			flag = &ws.notifications
+		default:
+			ws.sendError("Unknown channel, must be timeline or notifications")
+			continue
+		}
+
+		switch req.Type {
+		case "subscribe":
+			flag.Store(true)
+			ws.send(wsMessage{Type: "subscribed", Channel: req.Channel})
+		case "unsubscribe":
+			flag.Store(false)
+			ws.send(wsMessage{Type: "unsubscribed", Channel: req.Channel})
+		default:
+			ws.sendError("Unknown type, must be subscribe or unsubscribe")
+		}
+	}
+}
+
+func (ws *wsSession) send(msg wsMessage) error {
+	dat, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+
+	ws.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return ws.conn.WriteMessage(websocket.TextMessage, dat)
+}
+
+func (ws *wsSession) sendError(msg string) {
+	ws.send(wsMessage{Type: "error", Error: msg})
+}
+
+func (ws *wsSession) close(code int, reason string) {
+	ws.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteWait))
+}
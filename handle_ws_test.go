@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func wsURL(ts *httptest.Server, path string) string {
+	return "ws" + strings.TrimPrefix(ts.URL, "http") + path
+}
+
+func dialWebSocket(t *testing.T, ts *httptest.Server, user loggedInUser) *websocket.Conn {
+	t.Helper()
+
+	conn, resp, err := websocket.DefaultDialer.Dial(wsURL(ts, "/api/ws"), http.Header{"Authorization": {user.bearer()}})
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("Failed to open websocket (status %d): %v", status, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readWSMessage(t *testing.T, conn *websocket.Conn) wsMessage {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	messageType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("Failed to read websocket message: %v", err)
+	}
+	if messageType != websocket.TextMessage {
+		t.Fatalf("Expected text message, got type %d", messageType)
+	}
+
+	var msg wsMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("Failed to decode websocket message %q: %v", data, err)
+	}
+	return msg
+}
+
+func subscribeWS(t *testing.T, conn *websocket.Conn, channel string) {
+	t.Helper()
+
+	dat, _ := json.Marshal(wsRequest{Type: "subscribe", Channel: channel})
+	if err := conn.WriteMessage(websocket.TextMessage, dat); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	if msg := readWSMessage(t, conn); msg.Type != "subscribed" || msg.Channel != channel {
+		t.Fatalf("Expected subscribed to %s, got %+v", channel, msg)
+	}
+}
+
+func TestHandleWebSocket(t *testing.T) {
+	s := newTestServer(t)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+	carol := createAndLogin(t, s, "c@example.com", "password1")
+	follow(t, s, alice, bob)
+
+	conn := dialWebSocket(t, ts, alice)
+	subscribeWS(t, conn, channelTimeline)
+	subscribeWS(t, conn, channelNotifications)
+
+	unfollowed := createChirp(t, s, carol, "not followed")
+	createChirp(t, s, alice, "my own chirp")
+	followed := createChirp(t, s, bob, "followed")
+
+	msg := readWSMessage(t, conn)
+	if msg.Type != "chirp" || msg.Chirp == nil || msg.Chirp.ID != followed.ID {
+		t.Fatalf("Expected timeline chirp %v, got %+v", followed.ID, msg)
+	}
+
+	rec := doRequest(t, s, http.MethodPost, "/api/chirps/"+unfollowed.ID.String()+"/rechirp", bob.bearer(), nil)
+	expectStatus(t, rec, http.StatusCreated)
+	msg = readWSMessage(t, conn)
+	if msg.Type != "chirp" || msg.Chirp == nil || msg.Chirp.UserID != bob.ID || msg.Chirp.RechirpOf == nil || msg.Chirp.RechirpOf.ID != unfollowed.ID {
+		t.Fatalf("Expected bob's rechirp of %v, got %+v", unfollowed.ID, msg)
+	}
+
+	rec = doRequest(t, s, http.MethodPut, "/api/chirps/"+followed.ID.String()+"/like", alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+	mine := createChirp(t, s, alice, "like this")
+	rec = doRequest(t, s, http.MethodPut, "/api/chirps/"+mine.ID.String()+"/like", carol.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	msg = readWSMessage(t, conn)
+	if msg.Type != "notification" || msg.Notification == nil || msg.Notification.Kind != NotificationLike || msg.Notification.ActorID != carol.ID {
+		t.Fatalf("Expected like notification from %v, got %+v", carol.ID, msg)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"subscribe","channel":"everything"}`)); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if msg := readWSMessage(t, conn); msg.Type != "error" {
+		t.Fatalf("Expected error for unknown channel, got %+v", msg)
+	}
+}
+
+func TestHandleWebSocket_FollowChanges(t *testing.T) {
+	s := newTestServer(t)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+	bob := createAndLogin(t, s, "b@example.com", "password1")
+	carol := createAndLogin(t, s, "c@example.com", "password1")
+	follow(t, s, alice, bob)
+
+	conn := dialWebSocket(t, ts, alice)
+	subscribeWS(t, conn, channelTimeline)
+
+	// Follows made after the socket opened reach its timeline.
+	follow(t, s, alice, carol)
+	followed := createChirp(t, s, carol, "newly followed")
+	msg := readWSMessage(t, conn)
+	if msg.Type != "chirp" || msg.Chirp == nil || msg.Chirp.ID != followed.ID {
+		t.Fatalf("Expected chirp %v from a new follow, got %+v", followed.ID, msg)
+	}
+
+	rec := doRequest(t, s, http.MethodDelete, "/api/users/"+carol.ID.String()+"/follow", alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+	createChirp(t, s, carol, "unfollowed")
+	still := createChirp(t, s, bob, "still followed")
+	msg = readWSMessage(t, conn)
+	if msg.Type != "chirp" || msg.Chirp == nil || msg.Chirp.ID != still.ID {
+		t.Fatalf("Expected only chirp %v after unfollowing, got %+v", still.ID, msg)
+	}
+}
+
+func TestHandleWebSocket_Errors(t *testing.T) {
+	s := newTestServer(t)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL(ts, "/api/ws"), nil)
+	if !errors.Is(err, websocket.ErrBadHandshake) || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 without a token, got %v", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL(ts, "/api/ws?access_token="+alice.Token), nil)
+	if err != nil {
+		t.Fatalf("Expected access_token query parameter to authenticate, got %v", err)
+	}
+	conn.Close()
+
+	rec := doRequest(t, s, http.MethodGet, "/api/ws", alice.bearer(), nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestHandleWebSocket_ConnectionCap(t *testing.T) {
+	s := newTestServer(t)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+
+	var conns []*websocket.Conn
+	for range maxWebSocketsPerUser {
+		conns = append(conns, dialWebSocket(t, ts, alice))
+	}
+
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL(ts, "/api/ws"), http.Header{"Authorization": {alice.bearer()}})
+	if err == nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 past the connection cap, got %v", err)
+	}
+
+	conns[0].WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL(ts, "/api/ws"), http.Header{"Authorization": {alice.bearer()}})
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected a slot to free up after closing a connection: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHandleWebSocket_PingAndShutdown(t *testing.T) {
+	s := newTestServer(t)
+	s.heartbeatInterval = 20 * time.Millisecond
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	alice := createAndLogin(t, s, "a@example.com", "password1")
+
+	conn := dialWebSocket(t, ts, alice)
+
+	// ReadMessage answers the server's pings, which keeps the connection
+	// alive well past the pong deadline.
+	conn.SetReadDeadline(time.Now().Add(10 * s.heartbeatInterval))
+	if _, _, err := conn.ReadMessage(); err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("Expected the connection to stay open until the read timeout, got %v", err)
+	}
+
+	conn = dialWebSocket(t, ts, alice)
+	s.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var closeErr *websocket.CloseError
+	if _, _, err := conn.ReadMessage(); !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseTryAgainLater {
+		t.Fatalf("Expected close with %d on shutdown, got %v", websocket.CloseTryAgainLater, err)
+	}
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
@@ -13,6 +15,16 @@ import (
 
 const MaxBcryptPasswordBytes = 72
 
+const (
+	RoleUser	= "user"
+	RoleAdmin	= "admin"
+)
+
+type Claims struct {
+	Role	string	`json:"role,omitempty"`
+	jwt.RegisteredClaims
+}
+
 func HashPassword(password string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -26,12 +38,15 @@ func CheckPasswordHash(password, hash string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	claims := jwt.RegisteredClaims{
-		Issuer:				"chirpy",
-		IssuedAt:			jwt.NewNumericDate(time.Now()),
-		ExpiresAt:		jwt.NewNumericDate(time.Now().Add(expiresIn)),
-		Subject:			userID.String(),
+func MakeJWT(userID uuid.UUID, role string, tokenSecret string, expiresIn time.Duration) (string, error) {
+	claims := Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:				"chirpy",
+			IssuedAt:			jwt.NewNumericDate(time.Now()),
+			ExpiresAt:		jwt.NewNumericDate(time.Now().Add(expiresIn)),
+			Subject:			userID.String(),
+		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -45,7 +60,12 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 }
 
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
+	userID, _, err := ValidateJWTWithRole(tokenString, tokenSecret)
+	return userID, err
+}
+
+func ValidateJWTWithRole(tokenString, tokenSecret string) (uuid.UUID, string, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("Unexpected jwt signing method: %v", token.Header["alg"])
 		}
@@ -54,24 +74,24 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 	})
 
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, "", err
 	}
 
 	if !token.Valid {
-		return uuid.Nil, fmt.Errorf("Invalid token")
+		return uuid.Nil, "", fmt.Errorf("Invalid token")
 	}
 
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	claims, ok := token.Claims.(*Claims)
 	if !ok {
-		return uuid.Nil, fmt.Errorf("Error extracting jwt claims")
+		return uuid.Nil, "", fmt.Errorf("Error extracting jwt claims")
 	}
 
 	userID, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, "", err
 	}
 
-	return userID, nil
+	return userID, claims.Role, nil
 }
 
 var ErrorInvalidAuthHeader= errors.New("Invalid Authorization Header")
@@ -90,4 +110,26 @@ func GetBearerToken(headers http.Header) (string, error) {
 	return token, nil
 }
 
+func GetAPIKey(headers http.Header) (string, error) {
+	authHeader := headers.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "ApiKey ") {
+		return "", ErrorInvalidAuthHeader
+	}
+
+	key := strings.TrimPrefix(authHeader, "ApiKey ")
+	if key == "" {
+		return "", ErrorInvalidAuthHeader
+	}
+
+	return key, nil
+}
+
+func MakeRefreshToken() (string, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
 
+	return hex.EncodeToString(key), nil
+}
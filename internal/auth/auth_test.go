@@ -67,7 +67,7 @@ func TestMakeJWT_Success(t *testing.T) {
 	tokenSecret := "testing-secret"
 	expiresIn		:= time.Hour
 
-	tokenStr, err := MakeJWT(userID, tokenSecret, expiresIn)
+	tokenStr, err := MakeJWT(userID, RoleUser, tokenSecret, expiresIn)
 	if err != nil {
 		t.Fatalf("Expected no error in call to MakeJWT, got %v", err)
 	}
@@ -94,7 +94,7 @@ func TestValidateJWT_Success(t *testing.T) {
 	tokenSecret := "testing-secret"
 	expiresIn		:= time.Hour
 
-	tokenStr, err := MakeJWT(userID, tokenSecret, expiresIn)
+	tokenStr, err := MakeJWT(userID, RoleUser, tokenSecret, expiresIn)
 	if err != nil {
 		t.Fatalf("Expected no error in call to MakeJWT, got %v", err)
 	}
@@ -109,13 +109,41 @@ func TestValidateJWT_Success(t *testing.T) {
 	}
 }
 
+func TestValidateJWTWithRole(t *testing.T) {
+	userID 			:= uuid.New()
+	tokenSecret := "testing-secret"
+
+	tokenStr, err := MakeJWT(userID, RoleAdmin, tokenSecret, time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error in call to MakeJWT, got %v", err)
+	}
+
+	parsedUserID, role, err := ValidateJWTWithRole(tokenStr, tokenSecret)
+	if err != nil {
+		t.Fatalf("Expected no error in call to ValidateJWTWithRole, got %v", err)
+	}
+
+	if parsedUserID != userID {
+		t.Fatalf("Expected parsedUserID %v to equal userID %v", parsedUserID, userID)
+	}
+
+	if role != RoleAdmin {
+		t.Fatalf("Expected role %q, got %q", RoleAdmin, role)
+	}
+
+	_, _, err = ValidateJWTWithRole(tokenStr, "wrong-secret")
+	if err == nil {
+		t.Fatal("Expected error when validating with the wrong secret, got nil")
+	}
+}
+
 func TestValidateJWT_ExpiredToken(t *testing.T) {
 	userID := uuid.New()
 	tokenSecret := "test-secret"
 	expiresIn := -time.Hour // Token expired 1 hour ago
 
 	// Create an already expired token
-	tokenString, err := MakeJWT(userID, tokenSecret, expiresIn)
+	tokenString, err := MakeJWT(userID, RoleUser, tokenSecret, expiresIn)
 	if err != nil {
 		t.Fatalf("Failed to create token: %v", err)
 	}
@@ -188,3 +216,72 @@ func TestExtractBearerToken(t *testing.T) {
 		}
 	}
 }
+
+func TestExtractAPIKey(t *testing.T) {
+	tests := []struct {
+		name		  string
+		headerVal	string
+		expectErr	bool
+		expectVal	string
+	}{
+		{
+			"Valid key",
+			"ApiKey f271c81ff7084ee5b99a5091b42d486e",
+			false,
+			"f271c81ff7084ee5b99a5091b42d486e",
+		},
+		{
+			"Bearer prefix",
+			"Bearer f271c81ff7084ee5b99a5091b42d486e",
+			true,
+			"",
+		},
+		{
+			"Empty key",
+			"ApiKey ",
+			true,
+			"",
+		},
+		{
+			"Empty header",
+			"",
+			true,
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if tt.headerVal != "" {
+			req.Header.Set("Authorization", tt.headerVal)
+		}
+
+		got, err := GetAPIKey(req.Header)
+		if (err != nil) != tt.expectErr {
+			t.Errorf("%s: Expected error = %v, got %v", tt.name, tt.expectErr, err)
+		}
+		if got != tt.expectVal {
+			t.Errorf("%s: Expected key value = %v, got %v", tt.name, tt.expectVal, got)
+		}
+	}
+}
+
+func TestMakeRefreshToken(t *testing.T) {
+	token1, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("Expected no error in call to MakeRefreshToken, got %v", err)
+	}
+
+	if len(token1) != 64 {
+		t.Fatalf("Expected 64 character hex token, got %d characters", len(token1))
+	}
+
+	token2, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("Expected no error in call to MakeRefreshToken, got %v", err)
+	}
+
+	if token1 == token2 {
+		t.Fatalf("Expected two calls to MakeRefreshToken to return different tokens")
+	}
+}
@@ -0,0 +1,48 @@
+package chirptext
+
+import (
+	"github.com/rivo/uniseg"
+	"regexp"
+	"strings"
+)
+
+// URLLength is the weight every link carries regardless of how long it is,
+// the same flat rate other microblogs charge for shortened links.
+const URLLength = 23
+
+// MaxURLBytes is the longest link that still gets the flat URLLength weight.
+// Longer ones count character by character like any other text, so a
+// megabyte "URL" cannot slip past the length limit.
+const MaxURLBytes = 2048
+
+var urlPattern = regexp.MustCompile(`(?i)\bhttps?://\S+`)
+
+const urlTrailingPunctuation = `.,:;!?'")]}`
+
+// Length is the number of characters a chirp body counts against the limit:
+// user-perceived characters outside of links, plus URLLength per link of at
+// most MaxURLBytes.
+func Length(body string) int {
+	length := 0
+	last := 0
+
+	for _, loc := range urlPattern.FindAllStringIndex(body, -1) {
+		start, end := loc[0], loc[1]
+		trimmed := strings.TrimRight(body[start:end], urlTrailingPunctuation)
+		end = start + len(trimmed)
+		if len(trimmed) > MaxURLBytes {
+			continue
+		}
+
+		length += CountGraphemes(body[last:start]) + URLLength
+		last = end
+	}
+
+	return length + CountGraphemes(body[last:])
+}
+
+// CountGraphemes returns the number of user-perceived characters in s, the
+// extended grapheme clusters of Unicode UAX #29.
+func CountGraphemes(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
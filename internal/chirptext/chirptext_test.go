@@ -0,0 +1,71 @@
+package chirptext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountGraphemes(t *testing.T) {
+	tests := []struct {
+		name	string
+		input	string
+		want	int
+	}{
+		{"Empty", "", 0},
+		{"ASCII", "hello", 5},
+		{"Accented precomposed", "café", 4},
+		{"Combining accent", "cafe\u0301", 4},
+		{"Simple emoji", "😀😀😀", 3},
+		{"Skin tone modifier", "\U0001f44d\U0001f3fd", 1},
+		{"ZWJ family", "\U0001f468\u200d\U0001f469\u200d\U0001f467\u200d\U0001f466", 1},
+		{"ZWJ kiss", "\U0001f469\u200d\u2764\ufe0f\u200d\U0001f48b\u200d\U0001f468", 1},
+		{"Rainbow flag", "\U0001f3f3\ufe0f\u200d\U0001f308", 1},
+		{"ZWJ between letters", "a\u200db", 2},
+		{"Variation selector", "\u2764\ufe0f", 1},
+		{"Flags", "🇺🇸🇫🇷", 2},
+		{"Odd regional indicators", "🇺🇸🇫", 2},
+		{"Tag sequence", "\U0001f3f4\U000e0067\U000e0062\U000e0073\U000e0063\U000e0074\U000e007f", 1},
+		{"Hangul jamo", "\u1100\u1161\u11a8", 1},
+		{"Hangul syllables", "한국어", 3},
+		{"CRLF", "a\r\nb", 3},
+		{"CJK", "你好世界", 4},
+		{"Devanagari vowel sign", "\u0915\u093f", 1},
+		{"Devanagari word", "\u0928\u092e\u0938\u094d\u0924\u0947", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CountGraphemes(tt.input); got != tt.want {
+				t.Errorf("CountGraphemes(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLength(t *testing.T) {
+	longURL := "https://example.com/" + strings.Repeat("a", 100)
+
+	tests := []struct {
+		name	string
+		input	string
+		want	int
+	}{
+		{"No links", "hello world", 11},
+		{"Emoji", strings.Repeat("😀", 50), 50},
+		{"Long link", longURL, URLLength},
+		{"Oversized link", "https://a.co/" + strings.Repeat("a", MaxURLBytes), len("https://a.co/") + MaxURLBytes},
+		{"Short link", "http://a.co", URLLength},
+		{"Link in text", "see " + longURL + " now", 4 + URLLength + 4},
+		{"Trailing punctuation", "(" + longURL + ").", 1 + URLLength + 2},
+		{"Two links", longURL + " " + longURL, 2*URLLength + 1},
+		{"Not a link", "https:/nope", 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Length(tt.input); got != tt.want {
+				t.Errorf("Length(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
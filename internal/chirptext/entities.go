@@ -0,0 +1,160 @@
+package chirptext
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token is a sigil-prefixed word found in a body; Start and End are byte
+// offsets covering the sigil and the word.
+type token struct {
+	Text	string
+	Start	int
+	End		int
+}
+
+// findTokens returns every run of word characters introduced by sigil that
+// does not sit inside a link or directly after another word character, so
+// that "a#b" and "https://x.com/#top" are not tokens.
+func findTokens(body string, sigil rune) []token {
+	links := urlPattern.FindAllStringIndex(body, -1)
+	inLink := func(i int) bool {
+		for _, loc := range links {
+			if i >= loc[0] && i < loc[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var tokens []token
+	var prev rune
+	for i, r := range body {
+		if r != sigil || isWordRune(prev) || prev == sigil || inLink(i) {
+			prev = r
+			continue
+		}
+		prev = r
+
+		start := i + utf8.RuneLen(r)
+		end := start
+		for end < len(body) {
+			next, size := utf8.DecodeRuneInString(body[end:])
+			if !isWordRune(next) {
+				break
+			}
+			end += size
+		}
+
+		if end > start {
+			tokens = append(tokens, token{Text: body[start:end], Start: i, End: end})
+		}
+	}
+
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.In(r, unicode.Mn, unicode.Mc)
+}
+
+// NormalizeHashtag lowercases a tag and strips a leading '#'. It returns ""
+// for anything that would not be extracted as a hashtag from a body.
+func NormalizeHashtag(tag string) string {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if tag == "" || strings.IndexFunc(tag, unicode.IsLetter) < 0 {
+		return ""
+	}
+	for _, r := range tag {
+		if !isWordRune(r) {
+			return ""
+		}
+	}
+	return tag
+}
+
+// Hashtags returns the distinct normalized hashtags in body in the order they
+// first appear. Purely numeric tags such as "#1" are ignored.
+func Hashtags(body string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+
+	for _, tok := range findTokens(body, '#') {
+		tag := NormalizeHashtag(tok.Text)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// Username limits, matching what NormalizeUsername accepts.
+const (
+	MinUsernameLength	= 3
+	MaxUsernameLength	= 15
+)
+
+// NormalizeUsername lowercases a username and strips a leading '@'. It
+// returns "" unless the result is MinUsernameLength to MaxUsernameLength
+// ASCII letters, digits and underscores.
+func NormalizeUsername(name string) string {
+	name = strings.ToLower(strings.TrimPrefix(name, "@"))
+	if len(name) < MinUsernameLength || !validUsernameChars(name) {
+		return ""
+	}
+	return name
+}
+
+// NormalizeUsernamePrefix is NormalizeUsername for the start of a username,
+// so any non-empty prefix of a valid username is accepted.
+func NormalizeUsernamePrefix(prefix string) string {
+	prefix = strings.ToLower(strings.TrimPrefix(prefix, "@"))
+	if prefix == "" || !validUsernameChars(prefix) {
+		return ""
+	}
+	return prefix
+}
+
+func validUsernameChars(name string) bool {
+	if len(name) > MaxUsernameLength {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Mention is an @username in a body. Start and End are offsets in Unicode
+// code points, End exclusive, and cover the '@'.
+type Mention struct {
+	Username	string
+	Start			int
+	End				int
+}
+
+// Mentions returns every well-formed @username in body, in order.
+func Mentions(body string) []Mention {
+	var mentions []Mention
+
+	for _, tok := range findTokens(body, '@') {
+		username := NormalizeUsername(tok.Text)
+		if username == "" {
+			continue
+		}
+		start := utf8.RuneCountInString(body[:tok.Start])
+		mentions = append(mentions, Mention{
+			Username:	username,
+			Start:		start,
+			End:			start + utf8.RuneCountInString(body[tok.Start:tok.End]),
+		})
+	}
+
+	return mentions
+}
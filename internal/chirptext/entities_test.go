@@ -0,0 +1,115 @@
+package chirptext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		name	string
+		input	string
+		want	[]string
+	}{
+		{"None", "hello world", nil},
+		{"Single", "hello #Go", []string{"go"}},
+		{"Punctuation", "(#go), #chirpy!", []string{"go", "chirpy"}},
+		{"Duplicates", "#go #GO #go", []string{"go"}},
+		{"Underscore and digits", "#go_1_24", []string{"go_1_24"}},
+		{"Numeric only", "#1 #2024", nil},
+		{"Mid word", "a#b c##d", nil},
+		{"Link fragment", "https://example.com/#top #real", []string{"real"}},
+		{"Unicode", "#Café #日本", []string{"café", "日本"}},
+		{"Bare sigil", "# #", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Hashtags(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Hashtags(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	tests := []struct {
+		input	string
+		want	string
+	}{
+		{"Go", "go"},
+		{"#Go", "go"},
+		{"123", ""},
+		{"not-a-tag", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeHashtag(tt.input); got != tt.want {
+			t.Errorf("NormalizeHashtag(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name	string
+		input	string
+		want	[]Mention
+	}{
+		{"None", "hello world", nil},
+		{"Single", "hi @Bob!", []Mention{{"bob", 3, 7}}},
+		{"Code point offsets", "😀 @bob", []Mention{{"bob", 2, 6}}},
+		{"Email", "mail bob@example.com", nil},
+		{"Too short", "@ab @abc", []Mention{{"abc", 4, 8}}},
+		{"Too long", "@abcdefghijklmnop", nil},
+		{"Non ASCII", "@bobé", nil},
+		{"Repeated", "@bob @bob", []Mention{{"bob", 0, 4}, {"bob", 5, 9}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Mentions(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Mentions(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeUsername(t *testing.T) {
+	tests := []struct {
+		input	string
+		want	string
+	}{
+		{"Bob_1", "bob_1"},
+		{"@bob", "bob"},
+		{"bo", ""},
+		{"bob smith", ""},
+		{"abcdefghijklmnop", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeUsername(tt.input); got != tt.want {
+			t.Errorf("NormalizeUsername(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeUsernamePrefix(t *testing.T) {
+	tests := []struct {
+		input	string
+		want	string
+	}{
+		{"B", "b"},
+		{"@Bob_", "bob_"},
+		{"@", ""},
+		{"bo%", ""},
+		{"abcdefghijklmnop", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeUsernamePrefix(tt.input); got != tt.want {
+			t.Errorf("NormalizeUsernamePrefix(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package moderation
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+type Action string
+
+const (
+	ActionMask		Action = "mask"
+	ActionReject	Action = "reject"
+	ActionFlag		Action = "flag"
+)
+
+const Mask = "****"
+
+var ErrorInvalidAction = errors.New("Invalid action, must be mask, reject or flag")
+var ErrorInvalidWord = errors.New("Invalid word, must be a single word of letters or digits")
+
+type Rule struct {
+	Word		string
+	Action	Action
+}
+
+type Result struct {
+	Body			string
+	Rejected	bool
+	Flagged		bool
+	Matches		[]string
+}
+
+func ParseAction(s string) (Action, error) {
+	switch action := Action(strings.ToLower(s)); action {
+	case ActionMask, ActionReject, ActionFlag:
+		return action, nil
+	}
+	return "", ErrorInvalidAction
+}
+
+// NormalizeWord lowercases a rule word and checks that it is a single token
+// as Apply would split it; anything else could never match.
+func NormalizeWord(word string) (string, error) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" {
+		return "", ErrorInvalidWord
+	}
+	for _, r := range word {
+		if !isWordRune(r) {
+			return "", ErrorInvalidWord
+		}
+	}
+	return word, nil
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Apply checks every run of letters and digits in body against rules,
+// ignoring case. Surrounding punctuation and whitespace are left untouched,
+// so "Kerfuffle!" is caught and masked as "****!".
+func Apply(body string, rules []Rule) Result {
+	actions := make(map[string]Action, len(rules))
+	for _, rule := range rules {
+		actions[strings.ToLower(rule.Word)] = rule.Action
+	}
+
+	result := Result{}
+	var out strings.Builder
+	runes := []rune(body)
+
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			out.WriteRune(runes[i])
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		word := string(runes[i:j])
+		i = j
+
+		action, ok := actions[strings.ToLower(word)]
+		if !ok {
+			out.WriteString(word)
+			continue
+		}
+
+		result.Matches = append(result.Matches, strings.ToLower(word))
+		switch action {
+		case ActionMask:
+			out.WriteString(Mask)
+		case ActionReject:
+			result.Rejected = true
+			out.WriteString(word)
+		case ActionFlag:
+			result.Flagged = true
+			out.WriteString(word)
+		}
+	}
+
+	result.Body = out.String()
+	return result
+}
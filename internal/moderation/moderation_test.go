@@ -0,0 +1,99 @@
+package moderation
+
+import (
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	rules := []Rule{
+		{Word: "kerfuffle", Action: ActionMask},
+		{Word: "sharbert", Action: ActionMask},
+		{Word: "fornax", Action: ActionReject},
+		{Word: "gizmo", Action: ActionFlag},
+	}
+
+	tests := []struct {
+		name				string
+		body				string
+		wantBody		string
+		wantReject	bool
+		wantFlag		bool
+	}{
+		{
+			name:			"No matches",
+			body:			"Just a normal chirp",
+			wantBody:	"Just a normal chirp",
+		},
+		{
+			name:			"Mask ignores case",
+			body:			"What a KerFuffle today",
+			wantBody:	"What a **** today",
+		},
+		{
+			name:			"Mask keeps punctuation",
+			body:			"kerfuffle! (sharbert), ok?",
+			wantBody:	"****! (****), ok?",
+		},
+		{
+			name:			"Partial words do not match",
+			body:			"kerfuffles and sharberts",
+			wantBody:	"kerfuffles and sharberts",
+		},
+		{
+			name:				"Reject",
+			body:				"Fornax.",
+			wantBody:		"Fornax.",
+			wantReject:	true,
+		},
+		{
+			name:			"Flag",
+			body:			"check this #gizmo out",
+			wantBody:	"check this #gizmo out",
+			wantFlag:	true,
+		},
+		{
+			name:			"Whitespace preserved",
+			body:			"a  kerfuffle\tb",
+			wantBody:	"a  ****\tb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Apply(tt.body, rules)
+			if got.Body != tt.wantBody {
+				t.Errorf("Apply() body = %q, want %q", got.Body, tt.wantBody)
+			}
+			if got.Rejected != tt.wantReject {
+				t.Errorf("Apply() rejected = %v, want %v", got.Rejected, tt.wantReject)
+			}
+			if got.Flagged != tt.wantFlag {
+				t.Errorf("Apply() flagged = %v, want %v", got.Flagged, tt.wantFlag)
+			}
+		})
+	}
+}
+
+func TestNormalizeWord(t *testing.T) {
+	tests := []struct {
+		word		string
+		want		string
+		wantErr	bool
+	}{
+		{"Kerfuffle", "kerfuffle", false},
+		{"  fornax ", "fornax", false},
+		{"", "", true},
+		{"two words", "", true},
+		{"bad!", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeWord(tt.word)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeWord(%q) error = %v, wantErr %v", tt.word, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeWord(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
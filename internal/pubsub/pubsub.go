@@ -0,0 +1,105 @@
+package pubsub
+
+import (
+	"sync"
+)
+
+// Broker fans published values out to every subscriber whose filter accepts
+// them. Publish never blocks: a subscriber whose buffer is full is dropped
+// and its channel closed, leaving it to catch up from durable storage.
+type Broker[T any] struct {
+	mu						sync.Mutex
+	subscribers		map[*Subscription[T]]struct{}
+	closed				bool
+}
+
+type Subscription[T any] struct {
+	// C receives published values. It is closed when the subscription is
+	// closed, falls too far behind, or the broker shuts down.
+	C				<-chan T
+	c				chan T
+	filter	func(T) bool
+	broker	*Broker[T]
+}
+
+func NewBroker[T any]() *Broker[T] {
+	return &Broker[T]{
+		subscribers:	make(map[*Subscription[T]]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber that can fall up to buffer values behind.
+// A nil filter accepts everything. Subscribing to a closed broker returns a
+// subscription whose channel is already closed.
+func (b *Broker[T]) Subscribe(buffer int, filter func(T) bool) *Subscription[T] {
+	c := make(chan T, buffer)
+	sub := &Subscription[T]{
+		C:			c,
+		c:			c,
+		filter:	filter,
+		broker:	b,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(c)
+		return sub
+	}
+	b.subscribers[sub] = struct{}{}
+
+	return sub
+}
+
+func (b *Broker[T]) Publish(v T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		if sub.filter != nil && !sub.filter(v) {
+			continue
+		}
+		select {
+		case sub.c <- v:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Close drops every subscriber and refuses new ones.
+func (b *Broker[T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
+
+// Subscribers is the number of live subscriptions.
+func (b *Broker[T]) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subscribers)
+}
+
+// remove must be called with b.mu held.
+func (b *Broker[T]) remove(sub *Subscription[T]) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	close(sub.c)
+}
+
+// Close unsubscribes. It is safe to call more than once.
+func (s *Subscription[T]) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.broker.remove(s)
+}
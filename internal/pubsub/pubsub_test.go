@@ -0,0 +1,62 @@
+package pubsub
+
+import (
+	"testing"
+)
+
+func TestBroker_PublishFiltersSubscribers(t *testing.T) {
+	b := NewBroker[int]()
+	all := b.Subscribe(10, nil)
+	even := b.Subscribe(10, func(v int) bool { return v%2 == 0 })
+
+	for i := 1; i <= 4; i++ {
+		b.Publish(i)
+	}
+
+	if got := len(all.C); got != 4 {
+		t.Fatalf("Expected 4 values for unfiltered subscriber, got %d", got)
+	}
+	if got := len(even.C); got != 2 {
+		t.Fatalf("Expected 2 values for filtered subscriber, got %d", got)
+	}
+	if v := <-even.C; v != 2 {
+		t.Fatalf("Expected first even value 2, got %d", v)
+	}
+}
+
+func TestBroker_DropsSlowSubscriber(t *testing.T) {
+	b := NewBroker[int]()
+	slow := b.Subscribe(1, nil)
+
+	b.Publish(1)
+	b.Publish(2)
+
+	if v, ok := <-slow.C; !ok || v != 1 {
+		t.Fatalf("Expected buffered value 1, got %d (open %v)", v, ok)
+	}
+	if _, ok := <-slow.C; ok {
+		t.Fatalf("Expected slow subscriber's channel to be closed")
+	}
+	if b.Subscribers() != 0 {
+		t.Fatalf("Expected slow subscriber to be removed, have %d", b.Subscribers())
+	}
+}
+
+func TestBroker_Close(t *testing.T) {
+	b := NewBroker[int]()
+	sub := b.Subscribe(1, nil)
+	sub.Close()
+	sub.Close()
+
+	live := b.Subscribe(1, nil)
+	b.Close()
+	if _, ok := <-live.C; ok {
+		t.Fatalf("Expected subscription closed with the broker")
+	}
+
+	late := b.Subscribe(1, nil)
+	if _, ok := <-late.C; ok {
+		t.Fatalf("Expected subscription to a closed broker to be closed")
+	}
+	b.Publish(1)
+}
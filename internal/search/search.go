@@ -0,0 +1,148 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+const MaxQueryLength = 256
+
+var ErrorEmptyQuery = errors.New("Search query has no words")
+var ErrorQueryTooLong = errors.New("Search query is too long")
+var ErrorInvalidTSQuery = errors.New("Invalid tsquery")
+
+// Term is a word or a phrase of consecutive words. A prefix term matches any
+// word beginning with its last word.
+type Term struct {
+	Words		[]string
+	Prefix	bool
+}
+
+// Query matches text containing every one of its terms.
+type Query struct {
+	Terms	[]Term
+}
+
+// Parse reads a user's search box. "quoted words" form a phrase, a trailing
+// '*' makes a prefix search, and everything else is a word that must appear.
+// Punctuation is dropped, so "e-mail" becomes the phrase "e mail", matching
+// how Postgres tokenizes chirp bodies.
+func Parse(q string) (Query, error) {
+	if len(q) > MaxQueryLength {
+		return Query{}, ErrorQueryTooLong
+	}
+
+	var query Query
+	var chunks []string
+	for i, part := range strings.Split(q, `"`) {
+		if i%2 == 1 {
+			chunks = append(chunks, part)
+			continue
+		}
+		chunks = append(chunks, strings.Fields(part)...)
+	}
+
+	for _, chunk := range chunks {
+		words := Words(chunk)
+		if len(words) == 0 {
+			continue
+		}
+		query.Terms = append(query.Terms, Term{
+			Words:	words,
+			Prefix:	strings.HasSuffix(chunk, "*"),
+		})
+	}
+
+	if len(query.Terms) == 0 {
+		return Query{}, ErrorEmptyQuery
+	}
+	return query, nil
+}
+
+// Words splits text into lowercase runs of letters and digits.
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// TSQuery renders the query in to_tsquery syntax. Words only ever hold
+// letters and digits, so nothing needs escaping.
+func (q Query) TSQuery() string {
+	terms := make([]string, 0, len(q.Terms))
+	for _, term := range q.Terms {
+		rendered := strings.Join(term.Words, " <-> ")
+		if term.Prefix {
+			rendered += ":*"
+		}
+		if len(term.Words) > 1 {
+			rendered = "(" + rendered + ")"
+		}
+		terms = append(terms, rendered)
+	}
+	return strings.Join(terms, " & ")
+}
+
+// ParseTSQuery reads back the output of TSQuery.
+func ParseTSQuery(s string) (Query, error) {
+	var query Query
+	for _, rendered := range strings.Split(s, " & ") {
+		rendered = strings.TrimSuffix(strings.TrimPrefix(rendered, "("), ")")
+		term := Term{}
+		if strings.HasSuffix(rendered, ":*") {
+			term.Prefix = true
+			rendered = strings.TrimSuffix(rendered, ":*")
+		}
+		for _, word := range strings.Split(rendered, " <-> ") {
+			if word == "" || len(Words(word)) != 1 || Words(word)[0] != word {
+				return Query{}, ErrorInvalidTSQuery
+			}
+			term.Words = append(term.Words, word)
+		}
+		query.Terms = append(query.Terms, term)
+	}
+	return query, nil
+}
+
+// Match reports whether text contains every term, along with a rank: the
+// number of times the terms occur. Unlike Postgres it does not stem words or
+// drop stop words.
+func (q Query) Match(text string) (float32, bool) {
+	words := Words(text)
+	rank := 0
+	for _, term := range q.Terms {
+		count := term.count(words)
+		if count == 0 {
+			return 0, false
+		}
+		rank += count
+	}
+	return float32(rank), true
+}
+
+func (t Term) count(words []string) int {
+	count := 0
+	for i := 0; i+len(t.Words) <= len(words); i++ {
+		if t.matchAt(words[i:]) {
+			count++
+		}
+	}
+	return count
+}
+
+func (t Term) matchAt(words []string) bool {
+	last := len(t.Words) - 1
+	for j, want := range t.Words {
+		if j == last && t.Prefix {
+			if !strings.HasPrefix(words[j], want) {
+				return false
+			}
+			continue
+		}
+		if words[j] != want {
+			return false
+		}
+	}
+	return true
+}
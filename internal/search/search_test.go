@@ -0,0 +1,100 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name		string
+		input		string
+		tsquery	string
+		err			error
+	}{
+		{
+			name:			"Words",
+			input:		"Hello  World",
+			tsquery:	"hello & world",
+		},
+		{
+			name:			"Phrase",
+			input:		`"good morning" coffee`,
+			tsquery:	"(good <-> morning) & coffee",
+		},
+		{
+			name:			"Prefix",
+			input:		"chirp* \"new yor*\"",
+			tsquery:	"chirp:* & (new <-> yor:*)",
+		},
+		{
+			name:			"Punctuation",
+			input:		"e-mail it's & | !",
+			tsquery:	"(e <-> mail) & (it <-> s)",
+		},
+		{
+			name:		"Empty",
+			input:	` "" *** `,
+			err:		ErrorEmptyQuery,
+		},
+		{
+			name:		"Too long",
+			input:	strings.Repeat("a", MaxQueryLength+1),
+			err:		ErrorQueryTooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := Parse(tt.input)
+			if err != tt.err {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if got := query.TSQuery(); got != tt.tsquery {
+				t.Fatalf("Expected tsquery %q, got %q", tt.tsquery, got)
+			}
+
+			roundTrip, err := ParseTSQuery(query.TSQuery())
+			if err != nil || !reflect.DeepEqual(roundTrip, query) {
+				t.Fatalf("Expected tsquery to round trip to %+v, got %+v (%v)", query, roundTrip, err)
+			}
+		})
+	}
+}
+
+func TestParseTSQuery_Invalid(t *testing.T) {
+	for _, s := range []string{"", "a &b", "a | b", "(a <-> )", "Upper"} {
+		if _, err := ParseTSQuery(s); err != ErrorInvalidTSQuery {
+			t.Fatalf("Expected %q to be rejected, got %v", s, err)
+		}
+	}
+}
+
+func TestQuery_Match(t *testing.T) {
+	query, err := Parse(`"good morning" cof*`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		text	string
+		rank	float32
+		ok		bool
+	}{
+		{text: "Good morning! Coffee time", rank: 2, ok: true},
+		{text: "good morning, good morning, coffee and coffees", rank: 4, ok: true},
+		{text: "morning good coffee", ok: false},
+		{text: "good morning tea", ok: false},
+	}
+
+	for _, tt := range tests {
+		rank, ok := query.Match(tt.text)
+		if ok != tt.ok || rank != tt.rank {
+			t.Fatalf("Match(%q): expected (%v, %v), got (%v, %v)", tt.text, tt.rank, tt.ok, rank, ok)
+		}
+	}
+}
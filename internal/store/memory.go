@@ -0,0 +1,1219 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/database"
+	"github.com/voylento/chirpy/internal/search"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrorDuplicateEmail = errors.New("Email already in use")
+var ErrorDuplicateToken = errors.New("Refresh token already exists")
+var ErrorDuplicateRule = errors.New("Moderation rule already exists")
+var ErrorDuplicateRechirp = errors.New("Chirp already rechirped")
+var ErrorDuplicateUsername = errors.New("Username already in use")
+
+// defaultRole mirrors the default on the users.role column.
+const defaultRole = "user"
+
+// MemoryStore keeps everything in maps guarded by a single mutex. It mirrors
+// the Postgres behavior the handlers depend on: sql.ErrNoRows for missing
+// rows, unique emails, and cascading deletes from users.
+type MemoryStore struct {
+	mu							sync.Mutex
+	users						map[uuid.UUID]database.User
+	chirps					map[uuid.UUID]database.Chirp
+	refreshTokens		map[string]database.RefreshToken
+	moderationRules	map[uuid.UUID]database.ModerationRule
+	chirpFlags			map[uuid.UUID]database.ChirpFlag
+	follows					map[followKey]database.Follow
+	likes						map[likeKey]database.Like
+	hashtags				map[uuid.UUID][]string
+	mentions				map[uuid.UUID][]database.ChirpMention
+	notifications		map[uuid.UUID]database.Notification
+}
+
+type likeKey struct {
+	user		uuid.UUID
+	chirp		uuid.UUID
+}
+
+type followKey struct {
+	follower	uuid.UUID
+	followee	uuid.UUID
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:						make(map[uuid.UUID]database.User),
+		chirps:						make(map[uuid.UUID]database.Chirp),
+		refreshTokens:		make(map[string]database.RefreshToken),
+		moderationRules:	make(map[uuid.UUID]database.ModerationRule),
+		chirpFlags:				make(map[uuid.UUID]database.ChirpFlag),
+		follows:					make(map[followKey]database.Follow),
+		likes:						make(map[likeKey]database.Like),
+		hashtags:					make(map[uuid.UUID][]string),
+		mentions:					make(map[uuid.UUID][]database.ChirpMention),
+		notifications:		make(map[uuid.UUID]database.Notification),
+	}
+}
+
+// now matches the microsecond precision of a Postgres TIMESTAMP column.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// before reports whether (aTime, aID) sorts ahead of (bTime, bID), the same
+// row ordering the keyset queries use.
+func before(aTime time.Time, aID uuid.UUID, bTime time.Time, bID uuid.UUID) bool {
+	if !aTime.Equal(bTime) {
+		return aTime.Before(bTime)
+	}
+	return bytes.Compare(aID[:], bID[:]) < 0
+}
+
+func (s *MemoryStore) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.Email == arg.Email {
+			return database.User{}, ErrorDuplicateEmail
+		}
+		if user.Username == arg.Username {
+			return database.User{}, ErrorDuplicateUsername
+		}
+	}
+
+	createdAt := now()
+	user := database.User{
+		ID:							uuid.New(),
+		CreatedAt:			createdAt,
+		UpdatedAt:			createdAt,
+		Email:					arg.Email,
+		HashedPassword:	arg.HashedPassword,
+		Role:						defaultRole,
+		Username:				arg.Username,
+	}
+	s.users[user.ID] = user
+
+	return user, nil
+}
+
+func (s *MemoryStore) GetUser(ctx context.Context, email string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *MemoryStore) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+
+	return user, nil
+}
+
+func (s *MemoryStore) GetUserByUsername(ctx context.Context, username string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *MemoryStore) GetUsersPage(ctx context.Context, arg database.GetUsersPageParams) ([]database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var users []database.User
+	for _, user := range s.users {
+		if arg.AfterCreatedAt.Valid && !before(arg.AfterCreatedAt.Time, arg.AfterID.UUID, user.CreatedAt, user.ID) {
+			continue
+		}
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return before(users[i].CreatedAt, users[i].ID, users[j].CreatedAt, users[j].ID)
+	})
+
+	if len(users) > int(arg.PageLimit) {
+		users = users[:arg.PageLimit]
+	}
+
+	return users, nil
+}
+
+func (s *MemoryStore) SearchUsersPage(ctx context.Context, arg database.SearchUsersPageParams) ([]database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var users []database.User
+	for _, user := range s.users {
+		if !strings.HasPrefix(user.Username, arg.Prefix) {
+			continue
+		}
+		if arg.AfterCreatedAt.Valid && !before(arg.AfterCreatedAt.Time, arg.AfterID.UUID, user.CreatedAt, user.ID) {
+			continue
+		}
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return before(users[i].CreatedAt, users[i].ID, users[j].CreatedAt, users[j].ID)
+	})
+
+	if len(users) > int(arg.PageLimit) {
+		users = users[:arg.PageLimit]
+	}
+
+	return users, nil
+}
+
+func (s *MemoryStore) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var users []database.User
+	for _, id := range ids {
+		if user, ok := s.users[id]; ok {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+func (s *MemoryStore) GetUsersByUsernames(ctx context.Context, usernames []string) ([]database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var users []database.User
+	for _, user := range s.users {
+		if slices.Contains(usernames, user.Username) {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+func (s *MemoryStore) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.ID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+
+	for _, other := range s.users {
+		if other.ID != arg.ID && other.Email == arg.Email {
+			return database.User{}, ErrorDuplicateEmail
+		}
+		if other.ID != arg.ID && other.Username == arg.Username {
+			return database.User{}, ErrorDuplicateUsername
+		}
+	}
+
+	user.Email = arg.Email
+	user.HashedPassword = arg.HashedPassword
+	user.Username = arg.Username
+	user.UpdatedAt = now()
+	s.users[user.ID] = user
+
+	return user, nil
+}
+
+func (s *MemoryStore) UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.ID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+
+	user.DisplayName = arg.DisplayName
+	user.Bio = arg.Bio
+	user.Location = arg.Location
+	user.Website = arg.Website
+	user.AvatarURL = arg.AvatarURL
+	user.UpdatedAt = now()
+	s.users[user.ID] = user
+
+	return user, nil
+}
+
+func (s *MemoryStore) UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+
+	user.IsChirpyRed = true
+	user.UpdatedAt = now()
+	s.users[id] = user
+
+	return user, nil
+}
+
+func (s *MemoryStore) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.ID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+
+	user.Role = arg.Role
+	user.UpdatedAt = now()
+	s.users[arg.ID] = user
+
+	return user, nil
+}
+
+func (s *MemoryStore) DeleteAllUsers(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users = make(map[uuid.UUID]database.User)
+	s.chirps = make(map[uuid.UUID]database.Chirp)
+	s.refreshTokens = make(map[string]database.RefreshToken)
+	s.chirpFlags = make(map[uuid.UUID]database.ChirpFlag)
+	s.follows = make(map[followKey]database.Follow)
+	s.likes = make(map[likeKey]database.Like)
+	s.hashtags = make(map[uuid.UUID][]string)
+	s.mentions = make(map[uuid.UUID][]database.ChirpMention)
+	s.notifications = make(map[uuid.UUID]database.Notification)
+
+	return nil
+}
+
+func (s *MemoryStore) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Chirp{}, errors.New("Chirp references unknown user")
+	}
+	if arg.InReplyTo.Valid {
+		if _, ok := s.chirps[arg.InReplyTo.UUID]; !ok {
+			return database.Chirp{}, errors.New("Chirp replies to unknown chirp")
+		}
+	}
+	if arg.QuotedChirpID.Valid {
+		if _, ok := s.chirps[arg.QuotedChirpID.UUID]; !ok {
+			return database.Chirp{}, errors.New("Chirp quotes unknown chirp")
+		}
+	}
+
+	createdAt := now()
+	chirp := database.Chirp{
+		ID:							uuid.New(),
+		CreatedAt:			createdAt,
+		UpdatedAt:			createdAt,
+		Body:						arg.Body,
+		UserID:					arg.UserID,
+		InReplyTo:			arg.InReplyTo,
+		QuotedChirpID:	arg.QuotedChirpID,
+	}
+	s.chirps[chirp.ID] = chirp
+
+	return chirp, nil
+}
+
+func (s *MemoryStore) GetChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chirp, ok := s.chirps[id]
+	if !ok {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+
+	return chirp, nil
+}
+
+func (s *MemoryStore) GetChirpsPage(ctx context.Context, arg database.GetChirpsPageParams) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var chirps []database.Chirp
+	for _, chirp := range s.chirps {
+		if arg.AuthorID.Valid && chirp.UserID != arg.AuthorID.UUID {
+			continue
+		}
+		if arg.AfterCreatedAt.Valid && !before(arg.AfterCreatedAt.Time, arg.AfterID.UUID, chirp.CreatedAt, chirp.ID) {
+			continue
+		}
+		chirps = append(chirps, chirp)
+	}
+
+	sort.Slice(chirps, func(i, j int) bool {
+		return before(chirps[i].CreatedAt, chirps[i].ID, chirps[j].CreatedAt, chirps[j].ID)
+	})
+
+	if len(chirps) > int(arg.PageLimit) {
+		chirps = chirps[:arg.PageLimit]
+	}
+
+	return chirps, nil
+}
+
+func (s *MemoryStore) GetChirpsPageDesc(ctx context.Context, arg database.GetChirpsPageDescParams) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var chirps []database.Chirp
+	for _, chirp := range s.chirps {
+		if arg.AuthorID.Valid && chirp.UserID != arg.AuthorID.UUID {
+			continue
+		}
+		if arg.BeforeCreatedAt.Valid && !before(chirp.CreatedAt, chirp.ID, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID) {
+			continue
+		}
+		chirps = append(chirps, chirp)
+	}
+
+	sort.Slice(chirps, func(i, j int) bool {
+		return before(chirps[j].CreatedAt, chirps[j].ID, chirps[i].CreatedAt, chirps[i].ID)
+	})
+
+	if len(chirps) > int(arg.PageLimit) {
+		chirps = chirps[:arg.PageLimit]
+	}
+
+	return chirps, nil
+}
+
+func (s *MemoryStore) DeleteChirp(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteChirp(id)
+
+	return nil
+}
+
+// deleteChirp mirrors the foreign keys on chirps: rechirps, flags and likes
+// go with the chirp, while replies and quotes lose their reference.
+func (s *MemoryStore) deleteChirp(id uuid.UUID) {
+	delete(s.chirps, id)
+	delete(s.chirpFlags, id)
+	delete(s.hashtags, id)
+	delete(s.mentions, id)
+	for notificationID, notification := range s.notifications {
+		if notification.ChirpID.Valid && notification.ChirpID.UUID == id {
+			delete(s.notifications, notificationID)
+		}
+	}
+	for key := range s.likes {
+		if key.chirp == id {
+			delete(s.likes, key)
+		}
+	}
+	for otherID, other := range s.chirps {
+		if other.RechirpOf.Valid && other.RechirpOf.UUID == id {
+			s.deleteChirp(otherID)
+			continue
+		}
+		if other.InReplyTo.Valid && other.InReplyTo.UUID == id {
+			other.InReplyTo = uuid.NullUUID{}
+		}
+		s.chirps[otherID] = other
+	}
+}
+
+func (s *MemoryStore) CreateRechirp(ctx context.Context, arg database.CreateRechirpParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Chirp{}, errors.New("Rechirp references unknown user")
+	}
+	if _, ok := s.chirps[arg.RechirpOf.UUID]; !ok {
+		return database.Chirp{}, errors.New("Rechirp references unknown chirp")
+	}
+	for _, chirp := range s.chirps {
+		if chirp.UserID == arg.UserID && chirp.RechirpOf == arg.RechirpOf {
+			return database.Chirp{}, ErrorDuplicateRechirp
+		}
+	}
+
+	createdAt := now()
+	chirp := database.Chirp{
+		ID:					uuid.New(),
+		CreatedAt:	createdAt,
+		UpdatedAt:	createdAt,
+		UserID:			arg.UserID,
+		RechirpOf:	arg.RechirpOf,
+	}
+	s.chirps[chirp.ID] = chirp
+
+	return chirp, nil
+}
+
+func (s *MemoryStore) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var chirps []database.Chirp
+	for _, id := range ids {
+		if chirp, ok := s.chirps[id]; ok {
+			chirps = append(chirps, chirp)
+		}
+	}
+
+	return chirps, nil
+}
+
+func (s *MemoryStore) GetRechirpCounts(ctx context.Context, chirpIds []uuid.UUID) ([]database.GetRechirpCountsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[uuid.UUID]bool, len(chirpIds))
+	for _, id := range chirpIds {
+		wanted[id] = true
+	}
+
+	counts := make(map[uuid.UUID]int64)
+	for _, chirp := range s.chirps {
+		if chirp.RechirpOf.Valid && wanted[chirp.RechirpOf.UUID] {
+			counts[chirp.RechirpOf.UUID]++
+		}
+	}
+
+	var rows []database.GetRechirpCountsRow
+	for chirpID, count := range counts {
+		rows = append(rows, database.GetRechirpCountsRow{ChirpID: chirpID, RechirpCount: count})
+	}
+
+	return rows, nil
+}
+
+func (s *MemoryStore) GetReplyCounts(ctx context.Context, chirpIds []uuid.UUID) ([]database.GetReplyCountsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[uuid.UUID]bool, len(chirpIds))
+	for _, id := range chirpIds {
+		wanted[id] = true
+	}
+
+	counts := make(map[uuid.UUID]int64)
+	for _, chirp := range s.chirps {
+		if chirp.InReplyTo.Valid && wanted[chirp.InReplyTo.UUID] {
+			counts[chirp.InReplyTo.UUID]++
+		}
+	}
+
+	var rows []database.GetReplyCountsRow
+	for chirpID, count := range counts {
+		rows = append(rows, database.GetReplyCountsRow{ChirpID: chirpID, ReplyCount: count})
+	}
+
+	return rows, nil
+}
+
+func (s *MemoryStore) GetRepliesPage(ctx context.Context, arg database.GetRepliesPageParams) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var chirps []database.Chirp
+	for _, chirp := range s.chirps {
+		if !chirp.InReplyTo.Valid || chirp.InReplyTo.UUID != arg.ChirpID {
+			continue
+		}
+		if arg.AfterCreatedAt.Valid && !before(arg.AfterCreatedAt.Time, arg.AfterID.UUID, chirp.CreatedAt, chirp.ID) {
+			continue
+		}
+		chirps = append(chirps, chirp)
+	}
+
+	sort.Slice(chirps, func(i, j int) bool {
+		return before(chirps[i].CreatedAt, chirps[i].ID, chirps[j].CreatedAt, chirps[j].ID)
+	})
+
+	if len(chirps) > int(arg.PageLimit) {
+		chirps = chirps[:arg.PageLimit]
+	}
+
+	return chirps, nil
+}
+
+// GetChirpAncestors returns the chain of parents of id, root first.
+func (s *MemoryStore) GetChirpAncestors(ctx context.Context, id uuid.UUID) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ancestors []database.Chirp
+	chirp, ok := s.chirps[id]
+	for ok && chirp.InReplyTo.Valid {
+		chirp, ok = s.chirps[chirp.InReplyTo.UUID]
+		if ok {
+			ancestors = append([]database.Chirp{chirp}, ancestors...)
+		}
+	}
+
+	return ancestors, nil
+}
+
+func (s *MemoryStore) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.refreshTokens[arg.Token]; ok {
+		return database.RefreshToken{}, ErrorDuplicateToken
+	}
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.RefreshToken{}, errors.New("Refresh token references unknown user")
+	}
+
+	createdAt := now()
+	token := database.RefreshToken{
+		Token:			arg.Token,
+		CreatedAt:	createdAt,
+		UpdatedAt:	createdAt,
+		UserID:			arg.UserID,
+		ExpiresAt:	arg.ExpiresAt,
+	}
+	s.refreshTokens[token.Token] = token
+
+	return token, nil
+}
+
+func (s *MemoryStore) GetUserFromRefreshToken(ctx context.Context, token string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refreshToken, ok := s.refreshTokens[token]
+	if !ok || refreshToken.RevokedAt.Valid || !refreshToken.ExpiresAt.After(now()) {
+		return database.User{}, sql.ErrNoRows
+	}
+
+	user, ok := s.users[refreshToken.UserID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+
+	return user, nil
+}
+
+func (s *MemoryStore) RevokeRefreshToken(ctx context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refreshToken, ok := s.refreshTokens[token]
+	if !ok {
+		return nil
+	}
+
+	revokedAt := now()
+	refreshToken.RevokedAt = sql.NullTime{Time: revokedAt, Valid: true}
+	refreshToken.UpdatedAt = revokedAt
+	s.refreshTokens[token] = refreshToken
+
+	return nil
+}
+
+func (s *MemoryStore) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revokedAt := now()
+	for token, refreshToken := range s.refreshTokens {
+		if refreshToken.UserID != userID || refreshToken.RevokedAt.Valid {
+			continue
+		}
+		refreshToken.RevokedAt = sql.NullTime{Time: revokedAt, Valid: true}
+		refreshToken.UpdatedAt = revokedAt
+		s.refreshTokens[token] = refreshToken
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) CreateModerationRule(ctx context.Context, arg database.CreateModerationRuleParams) (database.ModerationRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, rule := range s.moderationRules {
+		if rule.Word == arg.Word {
+			return database.ModerationRule{}, ErrorDuplicateRule
+		}
+	}
+
+	createdAt := now()
+	rule := database.ModerationRule{
+		ID:					uuid.New(),
+		CreatedAt:	createdAt,
+		UpdatedAt:	createdAt,
+		Word:				arg.Word,
+		Action:			arg.Action,
+	}
+	s.moderationRules[rule.ID] = rule
+
+	return rule, nil
+}
+
+func (s *MemoryStore) ListModerationRules(ctx context.Context) ([]database.ModerationRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rules []database.ModerationRule
+	for _, rule := range s.moderationRules {
+		rules = append(rules, rule)
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Word < rules[j].Word
+	})
+
+	return rules, nil
+}
+
+func (s *MemoryStore) DeleteModerationRule(ctx context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.moderationRules[id]; !ok {
+		return 0, nil
+	}
+	delete(s.moderationRules, id)
+
+	return 1, nil
+}
+
+func (s *MemoryStore) FlagChirp(ctx context.Context, arg database.FlagChirpParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return errors.New("Flag references unknown chirp")
+	}
+
+	if _, ok := s.chirpFlags[arg.ChirpID]; ok {
+		return nil
+	}
+
+	s.chirpFlags[arg.ChirpID] = database.ChirpFlag{
+		ChirpID:		arg.ChirpID,
+		CreatedAt:	now(),
+		Reason:			arg.Reason,
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) ListFlaggedChirps(ctx context.Context) ([]database.ListFlaggedChirpsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rows []database.ListFlaggedChirpsRow
+	for _, flag := range s.chirpFlags {
+		chirp := s.chirps[flag.ChirpID]
+		rows = append(rows, database.ListFlaggedChirpsRow{
+			ID:							chirp.ID,
+			CreatedAt:			chirp.CreatedAt,
+			UpdatedAt:			chirp.UpdatedAt,
+			Body:						chirp.Body,
+			UserID:					chirp.UserID,
+			InReplyTo:			chirp.InReplyTo,
+			RechirpOf:			chirp.RechirpOf,
+			QuotedChirpID:	chirp.QuotedChirpID,
+			Reason:					flag.Reason,
+			FlaggedAt:			flag.CreatedAt,
+		})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return before(rows[i].FlaggedAt, rows[i].ID, rows[j].FlaggedAt, rows[j].ID)
+	})
+
+	return rows, nil
+}
+
+func (s *MemoryStore) FollowUser(ctx context.Context, arg database.FollowUserParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if arg.FollowerID == arg.FolloweeID {
+		return 0, errors.New("Users cannot follow themselves")
+	}
+	if _, ok := s.users[arg.FollowerID]; !ok {
+		return 0, errors.New("Follow references unknown user")
+	}
+	if _, ok := s.users[arg.FolloweeID]; !ok {
+		return 0, errors.New("Follow references unknown user")
+	}
+
+	key := followKey{follower: arg.FollowerID, followee: arg.FolloweeID}
+	if _, ok := s.follows[key]; ok {
+		return 0, nil
+	}
+
+	s.follows[key] = database.Follow{
+		FollowerID:	arg.FollowerID,
+		FolloweeID:	arg.FolloweeID,
+		CreatedAt:	now(),
+	}
+
+	return 1, nil
+}
+
+func (s *MemoryStore) UnfollowUser(ctx context.Context, arg database.UnfollowUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.follows, followKey{follower: arg.FollowerID, followee: arg.FolloweeID})
+
+	return nil
+}
+
+func (s *MemoryStore) GetFolloweeIDs(ctx context.Context, followerID uuid.UUID) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var followees []uuid.UUID
+	for key := range s.follows {
+		if key.follower == followerID {
+			followees = append(followees, key.followee)
+		}
+	}
+
+	return followees, nil
+}
+
+// followPage collects the other side of every follow edge matching keep,
+// ordered and paged the same way the follower/following queries are.
+func (s *MemoryStore) followPage(keep func(database.Follow) (uuid.UUID, bool), afterFollowedAt sql.NullTime, afterID uuid.NullUUID, limit int32) []database.GetFollowersPageRow {
+	var rows []database.GetFollowersPageRow
+	for _, follow := range s.follows {
+		otherID, ok := keep(follow)
+		if !ok {
+			continue
+		}
+		if afterFollowedAt.Valid && !before(afterFollowedAt.Time, afterID.UUID, follow.CreatedAt, otherID) {
+			continue
+		}
+
+		user := s.users[otherID]
+		rows = append(rows, database.GetFollowersPageRow{
+			ID:						user.ID,
+			CreatedAt:		user.CreatedAt,
+			Username:			user.Username,
+			IsChirpyRed:	user.IsChirpyRed,
+			DisplayName:	user.DisplayName,
+			Bio:					user.Bio,
+			Location:			user.Location,
+			Website:			user.Website,
+			AvatarURL:		user.AvatarURL,
+			FollowedAt:		follow.CreatedAt,
+		})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return before(rows[i].FollowedAt, rows[i].ID, rows[j].FollowedAt, rows[j].ID)
+	})
+
+	if len(rows) > int(limit) {
+		rows = rows[:limit]
+	}
+
+	return rows
+}
+
+func (s *MemoryStore) GetFollowersPage(ctx context.Context, arg database.GetFollowersPageParams) ([]database.GetFollowersPageRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.followPage(func(f database.Follow) (uuid.UUID, bool) {
+		return f.FollowerID, f.FolloweeID == arg.UserID
+	}, arg.AfterFollowedAt, arg.AfterID, arg.PageLimit), nil
+}
+
+func (s *MemoryStore) GetFollowingPage(ctx context.Context, arg database.GetFollowingPageParams) ([]database.GetFollowingPageRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows := s.followPage(func(f database.Follow) (uuid.UUID, bool) {
+		return f.FolloweeID, f.FollowerID == arg.UserID
+	}, arg.AfterFollowedAt, arg.AfterID, arg.PageLimit)
+
+	following := make([]database.GetFollowingPageRow, len(rows))
+	for i, row := range rows {
+		following[i] = database.GetFollowingPageRow(row)
+	}
+
+	return following, nil
+}
+
+func (s *MemoryStore) GetTimelinePage(ctx context.Context, arg database.GetTimelinePageParams) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var chirps []database.Chirp
+	for _, chirp := range s.chirps {
+		if _, ok := s.follows[followKey{follower: arg.UserID, followee: chirp.UserID}]; !ok {
+			continue
+		}
+		if arg.BeforeCreatedAt.Valid && !before(chirp.CreatedAt, chirp.ID, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID) {
+			continue
+		}
+		chirps = append(chirps, chirp)
+	}
+
+	sort.Slice(chirps, func(i, j int) bool {
+		return before(chirps[j].CreatedAt, chirps[j].ID, chirps[i].CreatedAt, chirps[i].ID)
+	})
+
+	if len(chirps) > int(arg.PageLimit) {
+		chirps = chirps[:arg.PageLimit]
+	}
+
+	return chirps, nil
+}
+
+func (s *MemoryStore) LikeChirp(ctx context.Context, arg database.LikeChirpParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return 0, errors.New("Like references unknown user")
+	}
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return 0, errors.New("Like references unknown chirp")
+	}
+
+	key := likeKey{user: arg.UserID, chirp: arg.ChirpID}
+	if _, ok := s.likes[key]; ok {
+		return 0, nil
+	}
+
+	s.likes[key] = database.Like{
+		UserID:			arg.UserID,
+		ChirpID:		arg.ChirpID,
+		CreatedAt:	now(),
+	}
+
+	return 1, nil
+}
+
+func (s *MemoryStore) UnlikeChirp(ctx context.Context, arg database.UnlikeChirpParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.likes, likeKey{user: arg.UserID, chirp: arg.ChirpID})
+
+	return nil
+}
+
+func (s *MemoryStore) GetLikeCounts(ctx context.Context, chirpIds []uuid.UUID) ([]database.GetLikeCountsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[uuid.UUID]bool, len(chirpIds))
+	for _, id := range chirpIds {
+		wanted[id] = true
+	}
+
+	counts := make(map[uuid.UUID]int64)
+	for key := range s.likes {
+		if wanted[key.chirp] {
+			counts[key.chirp]++
+		}
+	}
+
+	var rows []database.GetLikeCountsRow
+	for chirpID, count := range counts {
+		rows = append(rows, database.GetLikeCountsRow{ChirpID: chirpID, LikeCount: count})
+	}
+
+	return rows, nil
+}
+
+func (s *MemoryStore) GetLikedChirpIDs(ctx context.Context, arg database.GetLikedChirpIDsParams) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var liked []uuid.UUID
+	for _, chirpID := range arg.ChirpIds {
+		if _, ok := s.likes[likeKey{user: arg.UserID, chirp: chirpID}]; ok {
+			liked = append(liked, chirpID)
+		}
+	}
+
+	return liked, nil
+}
+
+func (s *MemoryStore) AddChirpHashtags(ctx context.Context, arg database.AddChirpHashtagsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return errors.New("Hashtag references unknown chirp")
+	}
+
+	for _, tag := range arg.Tags {
+		if !slices.Contains(s.hashtags[arg.ChirpID], tag) {
+			s.hashtags[arg.ChirpID] = append(s.hashtags[arg.ChirpID], tag)
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) GetChirpHashtags(ctx context.Context, chirpIds []uuid.UUID) ([]database.ChirpHashtag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rows []database.ChirpHashtag
+	for _, chirpID := range chirpIds {
+		for _, tag := range s.hashtags[chirpID] {
+			rows = append(rows, database.ChirpHashtag{ChirpID: chirpID, Tag: tag})
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Tag < rows[j].Tag
+	})
+
+	return rows, nil
+}
+
+func (s *MemoryStore) GetHashtagChirpsPage(ctx context.Context, arg database.GetHashtagChirpsPageParams) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var chirps []database.Chirp
+	for chirpID, tags := range s.hashtags {
+		if !slices.Contains(tags, arg.Tag) {
+			continue
+		}
+		chirp := s.chirps[chirpID]
+		if arg.BeforeCreatedAt.Valid && !before(chirp.CreatedAt, chirp.ID, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID) {
+			continue
+		}
+		chirps = append(chirps, chirp)
+	}
+
+	sort.Slice(chirps, func(i, j int) bool {
+		return before(chirps[j].CreatedAt, chirps[j].ID, chirps[i].CreatedAt, chirps[i].ID)
+	})
+
+	if len(chirps) > int(arg.PageLimit) {
+		chirps = chirps[:arg.PageLimit]
+	}
+
+	return chirps, nil
+}
+
+// SearchChirpsPage approximates Postgres full-text search with search.Query:
+// terms must match whole words or prefixes, without stemming or stop words,
+// and the rank is how often they occur.
+func (s *MemoryStore) SearchChirpsPage(ctx context.Context, arg database.SearchChirpsPageParams) ([]database.SearchChirpsPageRow, error) {
+	query, err := search.ParseTSQuery(arg.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// rowBefore reports whether a sorts before b in rank DESC, created_at
+	// DESC, id DESC order.
+	rowBefore := func(aRank float32, aTime time.Time, aID uuid.UUID, bRank float32, bTime time.Time, bID uuid.UUID) bool {
+		if aRank != bRank {
+			return aRank > bRank
+		}
+		return before(bTime, bID, aTime, aID)
+	}
+
+	var rows []database.SearchChirpsPageRow
+	for _, chirp := range s.chirps {
+		rank, ok := query.Match(chirp.Body)
+		if !ok {
+			continue
+		}
+		if arg.AuthorID.Valid && chirp.UserID != arg.AuthorID.UUID {
+			continue
+		}
+		if arg.Since.Valid && chirp.CreatedAt.Before(arg.Since.Time) {
+			continue
+		}
+		if arg.Until.Valid && !chirp.CreatedAt.Before(arg.Until.Time) {
+			continue
+		}
+		if arg.BeforeRank.Valid && !rowBefore(float32(arg.BeforeRank.Float64), arg.BeforeCreatedAt.Time, arg.BeforeID.UUID, rank, chirp.CreatedAt, chirp.ID) {
+			continue
+		}
+		rows = append(rows, database.SearchChirpsPageRow{
+			ID:							chirp.ID,
+			CreatedAt:			chirp.CreatedAt,
+			UpdatedAt:			chirp.UpdatedAt,
+			Body:						chirp.Body,
+			UserID:					chirp.UserID,
+			InReplyTo:			chirp.InReplyTo,
+			RechirpOf:			chirp.RechirpOf,
+			QuotedChirpID:	chirp.QuotedChirpID,
+			Rank:						rank,
+		})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rowBefore(rows[i].Rank, rows[i].CreatedAt, rows[i].ID, rows[j].Rank, rows[j].CreatedAt, rows[j].ID)
+	})
+
+	if len(rows) > int(arg.PageLimit) {
+		rows = rows[:arg.PageLimit]
+	}
+
+	return rows, nil
+}
+
+func (s *MemoryStore) AddChirpMention(ctx context.Context, arg database.AddChirpMentionParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return errors.New("Mention references unknown chirp")
+	}
+	if _, ok := s.users[arg.UserID]; !ok {
+		return errors.New("Mention references unknown user")
+	}
+
+	s.mentions[arg.ChirpID] = append(s.mentions[arg.ChirpID], database.ChirpMention{
+		ChirpID:			arg.ChirpID,
+		UserID:				arg.UserID,
+		StartOffset:	arg.StartOffset,
+		EndOffset:		arg.EndOffset,
+	})
+
+	return nil
+}
+
+func (s *MemoryStore) GetChirpMentions(ctx context.Context, chirpIds []uuid.UUID) ([]database.ChirpMention, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rows []database.ChirpMention
+	for _, chirpID := range chirpIds {
+		rows = append(rows, s.mentions[chirpID]...)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].ChirpID != rows[j].ChirpID {
+			return bytes.Compare(rows[i].ChirpID[:], rows[j].ChirpID[:]) < 0
+		}
+		return rows[i].StartOffset < rows[j].StartOffset
+	})
+
+	return rows, nil
+}
+
+func (s *MemoryStore) CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Notification{}, errors.New("Notification references unknown user")
+	}
+	if _, ok := s.users[arg.ActorID]; !ok {
+		return database.Notification{}, errors.New("Notification references unknown actor")
+	}
+
+	notification := database.Notification{
+		ID:					uuid.New(),
+		CreatedAt:	now(),
+		UserID:			arg.UserID,
+		ActorID:		arg.ActorID,
+		Kind:				arg.Kind,
+		ChirpID:		arg.ChirpID,
+	}
+	s.notifications[notification.ID] = notification
+
+	return notification, nil
+}
+
+func (s *MemoryStore) GetNotificationsPage(ctx context.Context, arg database.GetNotificationsPageParams) ([]database.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var notifications []database.Notification
+	for _, notification := range s.notifications {
+		if notification.UserID != arg.UserID {
+			continue
+		}
+		if arg.UnreadOnly && notification.ReadAt.Valid {
+			continue
+		}
+		if arg.BeforeCreatedAt.Valid && !before(notification.CreatedAt, notification.ID, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID) {
+			continue
+		}
+		notifications = append(notifications, notification)
+	}
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return before(notifications[j].CreatedAt, notifications[j].ID, notifications[i].CreatedAt, notifications[i].ID)
+	})
+
+	if len(notifications) > int(arg.PageLimit) {
+		notifications = notifications[:arg.PageLimit]
+	}
+
+	return notifications, nil
+}
+
+func (s *MemoryStore) MarkNotificationsRead(ctx context.Context, arg database.MarkNotificationsReadParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var marked int64
+	readAt := now()
+	for id, notification := range s.notifications {
+		if notification.UserID != arg.UserID || notification.ReadAt.Valid {
+			continue
+		}
+		if len(arg.Ids) > 0 && !slices.Contains(arg.Ids, id) {
+			continue
+		}
+		notification.ReadAt = sql.NullTime{Time: readAt, Valid: true}
+		s.notifications[id] = notification
+		marked++
+	}
+
+	return marked, nil
+}
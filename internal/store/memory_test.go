@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/database"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_CreateUserDuplicateEmail(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+
+	_, err := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("Expected no error creating first user, got %v", err)
+	}
+
+	_, err = s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	if !errors.Is(err, ErrorDuplicateEmail) {
+		t.Fatalf("Expected ErrorDuplicateEmail, got %v", err)
+	}
+}
+
+func TestMemoryStore_GetChirpNotFound(t *testing.T) {
+	s := NewMemoryStore()
+
+	_, err := s.GetChirp(context.Background(), uuid.New())
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestMemoryStore_GetChirpsPage(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+
+	user, err := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("Expected no error creating user, got %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		_, err := s.CreateChirp(ctx, database.CreateChirpParams{Body: "chirp", UserID: user.ID})
+		if err != nil {
+			t.Fatalf("Expected no error creating chirp, got %v", err)
+		}
+	}
+
+	first, err := s.GetChirpsPage(ctx, database.GetChirpsPageParams{PageLimit: 3})
+	if err != nil || len(first) != 3 {
+		t.Fatalf("Expected 3 chirps in first page, got %d (err %v)", len(first), err)
+	}
+
+	last := first[len(first)-1]
+	second, err := s.GetChirpsPage(ctx, database.GetChirpsPageParams{
+		AfterCreatedAt:	sql.NullTime{Time: last.CreatedAt, Valid: true},
+		AfterID:				uuid.NullUUID{UUID: last.ID, Valid: true},
+		PageLimit:			3,
+	})
+	if err != nil || len(second) != 2 {
+		t.Fatalf("Expected 2 chirps in second page, got %d (err %v)", len(second), err)
+	}
+
+	for _, chirp := range second {
+		for _, seen := range first {
+			if chirp.ID == seen.ID {
+				t.Fatalf("Chirp %v returned on both pages", chirp.ID)
+			}
+		}
+	}
+}
+
+func TestMemoryStore_RevokedRefreshToken(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+
+	user, err := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("Expected no error creating user, got %v", err)
+	}
+
+	_, err = s.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		Token:			"token",
+		UserID:			user.ID,
+		ExpiresAt:	time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error creating refresh token, got %v", err)
+	}
+
+	if _, err := s.GetUserFromRefreshToken(ctx, "token"); err != nil {
+		t.Fatalf("Expected valid refresh token, got %v", err)
+	}
+
+	if err := s.RevokeRefreshToken(ctx, "token"); err != nil {
+		t.Fatalf("Expected no error revoking token, got %v", err)
+	}
+
+	if _, err := s.GetUserFromRefreshToken(ctx, "token"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Expected sql.ErrNoRows for revoked token, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/voylento/chirpy/internal/database"
+)
+
+// Store is the set of persistence operations the HTTP handlers rely on.
+// *database.Queries satisfies it directly; MemoryStore is a stand-in for tests.
+type Store interface {
+	CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error)
+	GetUser(ctx context.Context, email string) (database.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error)
+	GetUserByUsername(ctx context.Context, username string) (database.User, error)
+	GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]database.User, error)
+	GetUsersByUsernames(ctx context.Context, usernames []string) ([]database.User, error)
+	GetUsersPage(ctx context.Context, arg database.GetUsersPageParams) ([]database.User, error)
+	SearchUsersPage(ctx context.Context, arg database.SearchUsersPageParams) ([]database.User, error)
+	UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error)
+	UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) (database.User, error)
+	UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) (database.User, error)
+	SetUserRole(ctx context.Context, arg database.SetUserRoleParams) (database.User, error)
+	DeleteAllUsers(ctx context.Context) error
+
+	CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error)
+	GetChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error)
+	GetChirpsPage(ctx context.Context, arg database.GetChirpsPageParams) ([]database.Chirp, error)
+	GetChirpsPageDesc(ctx context.Context, arg database.GetChirpsPageDescParams) ([]database.Chirp, error)
+	DeleteChirp(ctx context.Context, id uuid.UUID) error
+
+	GetReplyCounts(ctx context.Context, chirpIds []uuid.UUID) ([]database.GetReplyCountsRow, error)
+	GetRepliesPage(ctx context.Context, arg database.GetRepliesPageParams) ([]database.Chirp, error)
+	GetChirpAncestors(ctx context.Context, id uuid.UUID) ([]database.Chirp, error)
+
+	CreateRechirp(ctx context.Context, arg database.CreateRechirpParams) (database.Chirp, error)
+	GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]database.Chirp, error)
+	GetRechirpCounts(ctx context.Context, chirpIds []uuid.UUID) ([]database.GetRechirpCountsRow, error)
+
+	AddChirpHashtags(ctx context.Context, arg database.AddChirpHashtagsParams) error
+	GetChirpHashtags(ctx context.Context, chirpIds []uuid.UUID) ([]database.ChirpHashtag, error)
+	GetHashtagChirpsPage(ctx context.Context, arg database.GetHashtagChirpsPageParams) ([]database.Chirp, error)
+
+	SearchChirpsPage(ctx context.Context, arg database.SearchChirpsPageParams) ([]database.SearchChirpsPageRow, error)
+
+	AddChirpMention(ctx context.Context, arg database.AddChirpMentionParams) error
+	GetChirpMentions(ctx context.Context, chirpIds []uuid.UUID) ([]database.ChirpMention, error)
+
+	CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.Notification, error)
+	GetNotificationsPage(ctx context.Context, arg database.GetNotificationsPageParams) ([]database.Notification, error)
+	MarkNotificationsRead(ctx context.Context, arg database.MarkNotificationsReadParams) (int64, error)
+
+	CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error)
+	GetUserFromRefreshToken(ctx context.Context, token string) (database.User, error)
+	RevokeRefreshToken(ctx context.Context, token string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error
+
+	CreateModerationRule(ctx context.Context, arg database.CreateModerationRuleParams) (database.ModerationRule, error)
+	ListModerationRules(ctx context.Context) ([]database.ModerationRule, error)
+	DeleteModerationRule(ctx context.Context, id uuid.UUID) (int64, error)
+	FlagChirp(ctx context.Context, arg database.FlagChirpParams) error
+	ListFlaggedChirps(ctx context.Context) ([]database.ListFlaggedChirpsRow, error)
+
+	FollowUser(ctx context.Context, arg database.FollowUserParams) (int64, error)
+	UnfollowUser(ctx context.Context, arg database.UnfollowUserParams) error
+	GetFolloweeIDs(ctx context.Context, followerID uuid.UUID) ([]uuid.UUID, error)
+	GetFollowersPage(ctx context.Context, arg database.GetFollowersPageParams) ([]database.GetFollowersPageRow, error)
+	GetFollowingPage(ctx context.Context, arg database.GetFollowingPageParams) ([]database.GetFollowingPageRow, error)
+	GetTimelinePage(ctx context.Context, arg database.GetTimelinePageParams) ([]database.Chirp, error)
+
+	LikeChirp(ctx context.Context, arg database.LikeChirpParams) (int64, error)
+	UnlikeChirp(ctx context.Context, arg database.UnlikeChirpParams) error
+	GetLikeCounts(ctx context.Context, chirpIds []uuid.UUID) ([]database.GetLikeCountsRow, error)
+	GetLikedChirpIDs(ctx context.Context, arg database.GetLikedChirpIDsParams) ([]uuid.UUID, error)
+}
+
+var _ Store = (*database.Queries)(nil)
+
+const pqUniqueViolation = "23505"
+
+// IsUniqueViolation reports whether err came from a unique constraint, either
+// raised by Postgres or by MemoryStore.
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == pqUniqueViolation
+	}
+
+	return errors.Is(err, ErrorDuplicateEmail) ||
+		errors.Is(err, ErrorDuplicateToken) ||
+		errors.Is(err, ErrorDuplicateRule) ||
+		errors.Is(err, ErrorDuplicateRechirp) ||
+		errors.Is(err, ErrorDuplicateUsername)
+}
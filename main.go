@@ -2,25 +2,21 @@ package main
 
 import (
 	_ "github.com/lib/pq"
+	"context"
 	"database/sql"
+	"errors"
 	"github.com/joho/godotenv"
 	"github.com/voylento/chirpy/internal/database"
 	"log"
 	"net/http"
 	"os"
-	"sync/atomic"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 )
 
-type Config struct {
-	hits			atomic.Int32
-	db 				*database.Queries
-	platform	string
-	secret		string
-}
-
-var config *Config
-
-func InitializeApp() {
+func InitializeApp(filePathRoot string) *Server {
 	err := godotenv.Load()
 	if err != nil {
 		log.Fatalf("Error loading .env file: %v", err)
@@ -32,61 +28,106 @@ func InitializeApp() {
 		log.Fatalf("Unable to open database: %v", err)
 	}
 
+	maxChirpLength := 0
+	if maxStr := os.Getenv("MAX_CHIRP_LENGTH"); maxStr != "" {
+		maxChirpLength, err = strconv.Atoi(maxStr)
+		if err != nil || maxChirpLength <= 0 {
+			log.Fatalf("Invalid MAX_CHIRP_LENGTH %q", maxStr)
+		}
+	}
+
 	dbQueries := database.New(db)
 
-	config = &Config{
-		hits:	atomic.Int32{},
-		db:		dbQueries,
-		platform:	os.Getenv("PLATFORM"),
-		secret:		os.Getenv("SECRET"),
+	if adminEmail := os.Getenv("ADMIN_EMAIL"); adminEmail != "" {
+		admin, err := BootstrapAdmin(context.Background(), dbQueries, adminEmail, os.Getenv("ADMIN_PASSWORD"))
+		if err != nil {
+			log.Fatalf("Unable to bootstrap admin %s: %v", adminEmail, err)
+		}
+		log.Printf("Bootstrapped admin user %s (%s)", admin.Email, admin.ID)
 	}
+
+	return NewServer(ServerConfig{
+		Store:				dbQueries,
+		Platform:			os.Getenv("PLATFORM"),
+		Secret:				os.Getenv("SECRET"),
+		PolkaKey:			os.Getenv("POLKA_KEY"),
+		FilePathRoot:	filePathRoot,
+		MaxChirpLength:	maxChirpLength,
+		Logger:				log.Default(),
+	})
+}
+
+const (
+	defaultPort				= "8080"
+	readHeaderTimeout	= 5 * time.Second
+	readTimeout				= 15 * time.Second
+	writeTimeout			= 30 * time.Second
+	idleTimeout				= 120 * time.Second
+	shutdownTimeout		= 20 * time.Second
+)
+
+// listenAddr prefers a full ADDR (host:port) and otherwise listens on PORT
+// across all interfaces.
+func listenAddr() string {
+	if addr := os.Getenv("ADDR"); addr != "" {
+		return addr
+	}
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = defaultPort
+	}
+
+	return ":" + port
 }
 
 func main() {
-	const (
-		filePathRoot 	= "."
-		path					= "http://localhost:"
-		port 					= "8080"
-		appPath				= "/app/"
-		apiPath				= "/api/"
-		adminPath			= "/admin/"
-	)
-
-	mux := http.NewServeMux()
-	InitializeApp()
-
-	fileServer := http.FileServer(http.Dir(filePathRoot))
-	fileServerHandler := http.StripPrefix("/app", fileServer)
-
-	mux.Handle(appPath, config.MiddlewareMetricsInc(fileServerHandler))
-	mux.HandleFunc(createPath(http.MethodGet, apiPath, "users"), HandleGetUsers)
-	mux.HandleFunc(createPath(http.MethodPost, apiPath, "users"), HandleCreateUser)
-	mux.HandleFunc(createPath(http.MethodPost, apiPath, "login"), HandleLogin)
-	mux.HandleFunc(createPath(http.MethodGet, apiPath, "chirps/{chirpID}"), HandleGetChirp)
-	mux.HandleFunc(createPath(http.MethodGet, apiPath, "chirps"), HandleGetChirps)
-	mux.HandleFunc(createPath(http.MethodPost, apiPath, "chirps"), HandleCreateChirp)
-	mux.HandleFunc(createPath(http.MethodGet, apiPath,  "healthz"), HandleReadiness)
-	mux.HandleFunc(createPath(http.MethodGet, adminPath, "metrics"), config.HandleMetrics)
-	mux.HandleFunc(createPath(http.MethodPost, adminPath,  "reset"), config.HandleReset)
-	
+	const filePathRoot = "."
+
+	server := InitializeApp(filePathRoot)
+	addr := listenAddr()
+
 	srv := &http.Server{
-		Addr:			":" + port,
-		Handler: 	mux,
+		Addr:								addr,
+		Handler: 						server,
+		ReadHeaderTimeout:	readHeaderTimeout,
+		ReadTimeout:				readTimeout,
+		WriteTimeout:				writeTimeout,
+		IdleTimeout:				idleTimeout,
+	}
+	srv.RegisterOnShutdown(server.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Starting server at %s\n", addr)
+		log.Printf("Serving files from %s", filePathRoot + appPath)
+		log.Printf("Health check available at %s/api/healthz", addr)
+		log.Printf("Metrics available at %s/admin/metrics", addr)
+		log.Printf("Reset available at %s/admin/reset", addr)
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
+		}
+		return
+	case <-ctx.Done():
 	}
 
+	stop()
+	log.Printf("Shutting down, draining connections for up to %s", shutdownTimeout)
 
-	log.Printf("Starting server at http://localhost:%s\n", port)
-	log.Printf("Serving files from %s", filePathRoot + appPath)
-	log.Printf("Health check available at http://localhost:%s/api/healthz", port)
-	log.Printf("Metrics available at http://localhost:%s/api/metrics", port)
-	log.Printf("Reset metrics available at http://localhost:%s/api/reset", port)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 
-	if err := srv.ListenAndServe(); err != nil {
-		log.Fatalf("Server failed: %v", err)
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Fatalf("Server shutdown failed: %v", err)
 	}
-}
 
-func createPath(httpMethod string, path string, method  string) string {
-	return httpMethod + " " + path + method
+	log.Printf("Server stopped")
 }
-
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/chirptext"
+	"github.com/voylento/chirpy/internal/database"
+)
+
+type ChirpMention struct {
+	UserID		uuid.UUID		`json:"user_id"`
+	Start			int32				`json:"start"`
+	End				int32				`json:"end"`
+}
+
+// RecordMentions stores the @usernames in chirp's body that belong to real
+// users and notifies each of them once. Unknown usernames are left as text.
+func (s *Server) RecordMentions(ctx context.Context, chirp database.Chirp) error {
+	mentions := chirptext.Mentions(chirp.Body)
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	usernames := make([]string, len(mentions))
+	for i, mention := range mentions {
+		usernames[i] = mention.Username
+	}
+
+	users, err := s.db.GetUsersByUsernames(ctx, usernames)
+	if err != nil {
+		return err
+	}
+	byUsername := make(map[string]database.User, len(users))
+	for _, user := range users {
+		byUsername[user.Username] = user
+	}
+
+	notified := make(map[uuid.UUID]bool)
+	for _, mention := range mentions {
+		user, ok := byUsername[mention.Username]
+		if !ok {
+			continue
+		}
+
+		err = s.db.AddChirpMention(ctx, database.AddChirpMentionParams{
+			ChirpID:			chirp.ID,
+			UserID:				user.ID,
+			StartOffset:	int32(mention.Start),
+			EndOffset:		int32(mention.End),
+		})
+		if err != nil {
+			return err
+		}
+
+		if !notified[user.ID] {
+			notified[user.ID] = true
+			s.Notify(ctx, user.ID, chirp.UserID, NotificationMention, uuid.NullUUID{UUID: chirp.ID, Valid: true})
+		}
+	}
+
+	return nil
+}
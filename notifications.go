@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/database"
+)
+
+// Notification kinds, stored in notifications.kind.
+const (
+	NotificationMention	= "mention"
+	NotificationReply		= "reply"
+	NotificationLike		= "like"
+	NotificationFollow	= "follow"
+)
+
+// Notify records a notification for userID about something actorID did.
+// Acting on your own content is not news, so those are skipped. Failures
+// are logged rather than returned: the action that triggered the
+// notification has already succeeded.
+func (s *Server) Notify(ctx context.Context, userID, actorID uuid.UUID, kind string, chirpID uuid.NullUUID) {
+	if userID == actorID {
+		return
+	}
+
+	notification, err := s.db.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID:		userID,
+		ActorID:	actorID,
+		Kind:			kind,
+		ChirpID:	chirpID,
+	})
+	if err != nil {
+		s.logger.Printf("Unable to create %s notification for %v: %v\n", kind, userID, err)
+		return
+	}
+
+	s.notifications.Publish(notification)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+)
+
+var ErrorInvalidCursor = errors.New("Invalid cursor")
+
+// Cursor marks the last row of a page. Rows are ordered by (created_at, id),
+// so the pair uniquely identifies a position even when timestamps collide.
+// Search results are ordered by rank first, so their cursors carry it too.
+type Cursor struct {
+	CreatedAt	time.Time
+	ID				uuid.UUID
+	Rank			*float32
+}
+
+func EncodeCursor(c Cursor) string {
+	raw := c.CreatedAt.Format(time.RFC3339Nano) + "|" + c.ID.String()
+	if c.Rank != nil {
+		raw += "|" + strconv.FormatFloat(float64(*c.Rank), 'g', -1, 32)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrorInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 2 && len(parts) != 3 {
+		return Cursor{}, ErrorInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return Cursor{}, ErrorInvalidCursor
+	}
+
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return Cursor{}, ErrorInvalidCursor
+	}
+
+	cursor := Cursor{CreatedAt: createdAt, ID: id}
+	if len(parts) == 3 {
+		rank, err := strconv.ParseFloat(parts[2], 32)
+		if err != nil {
+			return Cursor{}, ErrorInvalidCursor
+		}
+		rank32 := float32(rank)
+		cursor.Rank = &rank32
+	}
+
+	return cursor, nil
+}
+
+type Page struct {
+	Limit		int
+	After		*Cursor
+}
+
+// ParsePage reads the limit and cursor query parameters. A missing limit
+// falls back to defaultPageLimit; a missing cursor starts from the beginning.
+func ParsePage(r *http.Request) (Page, error) {
+	page := Page{Limit: defaultPageLimit}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return Page{}, fmt.Errorf("Invalid limit, must be between 1 and %d", maxPageLimit)
+		}
+		page.Limit = limit
+	}
+
+	if cursorStr := r.URL.Query().Get("cursor"); cursorStr != "" {
+		cursor, err := DecodeCursor(cursorStr)
+		if err != nil {
+			return Page{}, err
+		}
+		page.After = &cursor
+	}
+
+	return page, nil
+}
+
+// QueryLimit is one more than the page size so a handler can tell whether
+// another page follows without a separate count query.
+func (p Page) QueryLimit() int32 {
+	return int32(p.Limit + 1)
+}
+
+func (p Page) AfterCreatedAt() sql.NullTime {
+	if p.After == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: p.After.CreatedAt, Valid: true}
+}
+
+func (p Page) AfterRank() sql.NullFloat64 {
+	if p.After == nil || p.After.Rank == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: float64(*p.After.Rank), Valid: true}
+}
+
+func (p Page) AfterID() uuid.NullUUID {
+	if p.After == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: p.After.ID, Valid: true}
+}
+
+// SetNextLink adds a Link header pointing at the next page, carrying over
+// the request's other query parameters.
+func SetNextLink(w http.ResponseWriter, r *http.Request, limit int, next Cursor) {
+	query := url.Values{}
+	for key, values := range r.URL.Query() {
+		query[key] = values
+	}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("cursor", EncodeCursor(next))
+
+	nextURL := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", nextURL.String()))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"github.com/voylento/chirpy/internal/chirptext"
+	"github.com/voylento/chirpy/internal/database"
+	"net/url"
+	"strings"
+)
+
+const (
+	maxDisplayNameLength	= 50
+	maxBioLength					= 160
+	maxLocationLength			= 30
+	maxProfileURLLength		= 2048
+)
+
+// Profile is the user-editable part of an account. A nil field in a PATCH
+// body leaves the stored value alone; an empty string clears it.
+type Profile struct {
+	DisplayName		*string		`json:"display_name"`
+	Bio						*string		`json:"bio"`
+	Location			*string		`json:"location"`
+	Website				*string		`json:"website"`
+	AvatarURL			*string		`json:"avatar_url"`
+}
+
+// Apply merges p over user's current profile and validates the result.
+func (p Profile) Apply(user database.User) (database.UpdateUserProfileParams, error) {
+	params := database.UpdateUserProfileParams{
+		ID:						user.ID,
+		DisplayName:	user.DisplayName,
+		Bio:					user.Bio,
+		Location:			user.Location,
+		Website:			user.Website,
+		AvatarURL:		user.AvatarURL,
+	}
+
+	var err error
+	if p.DisplayName != nil {
+		if params.DisplayName, err = profileText("display_name", *p.DisplayName, maxDisplayNameLength); err != nil {
+			return params, err
+		}
+	}
+	if p.Bio != nil {
+		if params.Bio, err = profileText("bio", *p.Bio, maxBioLength); err != nil {
+			return params, err
+		}
+	}
+	if p.Location != nil {
+		if params.Location, err = profileText("location", *p.Location, maxLocationLength); err != nil {
+			return params, err
+		}
+	}
+	if p.Website != nil {
+		if params.Website, err = profileURL("website", *p.Website); err != nil {
+			return params, err
+		}
+	}
+	if p.AvatarURL != nil {
+		if params.AvatarURL, err = profileURL("avatar_url", *p.AvatarURL); err != nil {
+			return params, err
+		}
+	}
+
+	return params, nil
+}
+
+// profileText trims value and measures it the way chirps are measured, so a
+// flag emoji counts as one character.
+func profileText(field, value string, maxLength int) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.ContainsFunc(value, isProfileControl) {
+		return "", fmt.Errorf("Invalid %s, must not contain control characters", field)
+	}
+	if chirptext.CountGraphemes(value) > maxLength {
+		return "", fmt.Errorf("Invalid %s, must be at most %d characters", field, maxLength)
+	}
+	return value, nil
+}
+
+// isProfileControl allows the newlines a bio may contain but nothing else
+// below space.
+func isProfileControl(r rune) bool {
+	return (r < ' ' && r != '\n') || r == 0x7f
+}
+
+func profileURL(field, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if len(value) > maxProfileURLLength {
+		return "", fmt.Errorf("Invalid %s, must be at most %d characters", field, maxProfileURLLength)
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("Invalid %s, must be an http or https URL", field)
+	}
+	return u.String(), nil
+}
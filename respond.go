@@ -2,30 +2,29 @@ package main
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 )
 
-func RespondWithError(w http.ResponseWriter, code int, msg string, err error) {
+func (s *Server) RespondWithError(w http.ResponseWriter, code int, msg string, err error) {
 	if err != nil {
-		log.Println(err)
+		s.logger.Println(err)
 	}
 	if code > 499 {
-		log.Printf("Responding with 5xx error: %s\n", msg)
+		s.logger.Printf("Responding with 5xx error: %s\n", msg)
 	}
 	type errorResponse struct {
 		Error	string `json:"error"`
 	}
-	RespondWithJSON(w, code, errorResponse {
+	s.RespondWithJSON(w, code, errorResponse {
 		Error: msg,
 	})
 }
 
-func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+func (s *Server) RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	dat, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshalling JSON: %s\n", err)
+		s.logger.Printf("Error marshalling JSON: %s\n", err)
 		w.WriteHeader(500)
 		return
 	}
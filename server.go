@@ -0,0 +1,172 @@
+package main
+
+import (
+	"github.com/google/uuid"
+	"github.com/voylento/chirpy/internal/database"
+	"github.com/voylento/chirpy/internal/pubsub"
+	"github.com/voylento/chirpy/internal/store"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	appPath		= "/app/"
+	apiPath		= "/api/"
+	adminPath	= "/admin/"
+	defaultMaxChirpLength = 140
+	maxRequestBodyBytes = 64 << 10
+)
+
+type ServerConfig struct {
+	Store					store.Store
+	Platform			string
+	Secret				string
+	PolkaKey			string
+	FilePathRoot	string
+	MaxChirpLength	int
+	Clock					func() time.Time
+	Logger				*log.Logger
+}
+
+// Server owns everything a request handler needs. Each instance has its own
+// mux and hit counter, so several can run side by side in one process.
+type Server struct {
+	hits			atomic.Int32
+	db 				store.Store
+	platform	string
+	secret		string
+	polkaKey	string
+	maxChirpLength	int
+	now				func() time.Time
+	logger		*log.Logger
+	mux				*http.ServeMux
+	chirps		*pubsub.Broker[Chirp]
+	notifications	*pubsub.Broker[database.Notification]
+	heartbeatInterval	time.Duration
+	wsMu			sync.Mutex
+	wsConns		map[uuid.UUID]int
+	wsSessions	map[uuid.UUID]map[*wsSession]bool
+}
+
+func NewServer(cfg ServerConfig) *Server {
+	s := &Server{
+		db:				cfg.Store,
+		platform:	cfg.Platform,
+		secret:		cfg.Secret,
+		polkaKey:	cfg.PolkaKey,
+		maxChirpLength:	cfg.MaxChirpLength,
+		now:			cfg.Clock,
+		logger:		cfg.Logger,
+		mux:			http.NewServeMux(),
+		chirps:		pubsub.NewBroker[Chirp](),
+		notifications:	pubsub.NewBroker[database.Notification](),
+		heartbeatInterval:	defaultHeartbeatInterval,
+		wsConns:	make(map[uuid.UUID]int),
+		wsSessions:	make(map[uuid.UUID]map[*wsSession]bool),
+	}
+
+	if s.now == nil {
+		s.now = time.Now
+	}
+	if s.logger == nil {
+		s.logger = log.Default()
+	}
+	if s.maxChirpLength <= 0 {
+		s.maxChirpLength = defaultMaxChirpLength
+	}
+
+	filePathRoot := cfg.FilePathRoot
+	if filePathRoot == "" {
+		filePathRoot = "."
+	}
+
+	s.routes(filePathRoot)
+
+	return s
+}
+
+// ServeHTTP caps every request body up front; nothing the API accepts comes
+// close to maxRequestBodyBytes, so no handler has to remember to.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close ends every open stream and websocket. Streams never go idle on their
+// own and websockets are hijacked out of the http.Server's reach, so this has
+// to run when it shuts down.
+func (s *Server) Close() {
+	s.chirps.Close()
+	s.notifications.Close()
+}
+
+func (s *Server) routes(filePathRoot string) {
+	fileServer := http.FileServer(http.Dir(filePathRoot))
+	fileServerHandler := http.StripPrefix("/app", fileServer)
+
+	s.mux.Handle(appPath, s.MiddlewareMetricsInc(fileServerHandler))
+	s.mux.Handle(adminPath, s.MiddlewareRequireAdmin(s.adminRoutes()))
+	s.mux.HandleFunc(createPath(http.MethodPost, apiPath, "users"), s.HandleCreateUser)
+	s.mux.HandleFunc(createPath(http.MethodPut, apiPath, "users"), s.HandleUpdateUser)
+	s.mux.HandleFunc(createPath(http.MethodPatch, apiPath, "users/me"), s.HandleUpdateProfile)
+	s.mux.HandleFunc(createPath(http.MethodGet, apiPath, "users/search"), s.HandleSearchUsers)
+	s.mux.HandleFunc(createPath(http.MethodGet, apiPath, "users/{userID}"), s.HandleGetUser)
+	s.mux.HandleFunc(createPath(http.MethodPost, apiPath, "users/{userID}/follow"), s.HandleFollowUser)
+	s.mux.HandleFunc(createPath(http.MethodDelete, apiPath, "users/{userID}/follow"), s.HandleUnfollowUser)
+	s.mux.HandleFunc(createPath(http.MethodGet, apiPath, "users/by-username/{username}"), s.HandleGetUserByUsername)
+	s.mux.Handle(createPath(http.MethodGet, apiPath, "users/{userID}/{resource}"), s.userListRoutes())
+	s.mux.HandleFunc(createPath(http.MethodGet, apiPath, "timeline"), s.HandleGetTimeline)
+	s.mux.HandleFunc(createPath(http.MethodGet, apiPath, "notifications"), s.HandleGetNotifications)
+	s.mux.HandleFunc(createPath(http.MethodPost, apiPath, "notifications/read"), s.HandleMarkNotificationsRead)
+	s.mux.HandleFunc(createPath(http.MethodPost, apiPath, "login"), s.HandleLogin)
+	s.mux.HandleFunc(createPath(http.MethodPost, apiPath, "refresh"), s.HandleRefresh)
+	s.mux.HandleFunc(createPath(http.MethodPost, apiPath, "revoke"), s.HandleRevoke)
+	s.mux.HandleFunc(createPath(http.MethodGet, apiPath, "chirps/{chirpID}"), s.HandleGetChirp)
+	s.mux.HandleFunc(createPath(http.MethodDelete, apiPath, "chirps/{chirpID}"), s.HandleDeleteChirp)
+	s.mux.HandleFunc(createPath(http.MethodGet, apiPath, "chirps/{chirpID}/thread"), s.HandleGetThread)
+	s.mux.HandleFunc(createPath(http.MethodPost, apiPath, "chirps/{chirpID}/rechirp"), s.HandleRechirp)
+	s.mux.HandleFunc(createPath(http.MethodPut, apiPath, "chirps/{chirpID}/like"), s.HandleLikeChirp)
+	s.mux.HandleFunc(createPath(http.MethodDelete, apiPath, "chirps/{chirpID}/like"), s.HandleUnlikeChirp)
+	s.mux.HandleFunc(createPath(http.MethodGet, apiPath, "chirps"), s.HandleGetChirps)
+	s.mux.HandleFunc(createPath(http.MethodPost, apiPath, "chirps"), s.HandleCreateChirp)
+	s.mux.HandleFunc(createPath(http.MethodGet, apiPath, "hashtags/{tag}/chirps"), s.HandleGetHashtagChirps)
+	s.mux.HandleFunc(createPath(http.MethodGet, apiPath, "search/chirps"), s.HandleSearchChirps)
+	s.mux.HandleFunc(createPath(http.MethodGet, apiPath, "stream"), s.HandleStream)
+	s.mux.HandleFunc(createPath(http.MethodGet, apiPath, "ws"), s.HandleWebSocket)
+	s.mux.HandleFunc(createPath(http.MethodPost, apiPath, "polka/webhooks"), s.HandlePolkaWebhook)
+	s.mux.HandleFunc(createPath(http.MethodGet, apiPath,  "healthz"), HandleReadiness)
+}
+
+func (s *Server) adminRoutes() http.Handler {
+	adminMux := http.NewServeMux()
+	adminMux.HandleFunc(createPath(http.MethodGet, adminPath, "metrics"), s.HandleMetrics)
+	adminMux.HandleFunc(createPath(http.MethodPost, adminPath,  "reset"), s.HandleReset)
+	adminMux.HandleFunc(createPath(http.MethodGet, adminPath, "moderation/rules"), s.HandleListModerationRules)
+	adminMux.HandleFunc(createPath(http.MethodPost, adminPath, "moderation/rules"), s.HandleCreateModerationRule)
+	adminMux.HandleFunc(createPath(http.MethodDelete, adminPath, "moderation/rules/{ruleID}"), s.HandleDeleteModerationRule)
+	adminMux.HandleFunc(createPath(http.MethodGet, adminPath, "moderation/flagged"), s.HandleListFlaggedChirps)
+	adminMux.HandleFunc(createPath(http.MethodGet, adminPath, "users"), s.HandleGetUsers)
+	adminMux.HandleFunc(createPath(http.MethodPut, adminPath, "users/{userID}/role"), s.HandleSetUserRole)
+
+	return adminMux
+}
+
+// userListRoutes serves the listings under /api/users/{userID}/. They live on
+// their own mux because users/{userID}/followers and users/by-username/{name}
+// both match /api/users/by-username/followers and neither is more specific,
+// which ServeMux refuses; users/{userID}/{resource} is less specific than
+// users/by-username/{username}, so the two can share the top-level mux.
+func (s *Server) userListRoutes() http.Handler {
+	userMux := http.NewServeMux()
+	userMux.HandleFunc(createPath(http.MethodGet, apiPath, "users/{userID}/followers"), s.HandleGetFollowers)
+	userMux.HandleFunc(createPath(http.MethodGet, apiPath, "users/{userID}/following"), s.HandleGetFollowing)
+
+	return userMux
+}
+
+func createPath(httpMethod string, path string, method  string) string {
+	return httpMethod + " " + path + method
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/voylento/chirpy/internal/database"
+	"github.com/voylento/chirpy/internal/store"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testSecret = "test-secret"
+const testPolkaKey = "test-polka-key"
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	return NewServer(ServerConfig{
+		Store:		store.NewMemoryStore(),
+		Platform:	"dev",
+		Secret:		testSecret,
+		PolkaKey:	testPolkaKey,
+		Logger:		log.New(io.Discard, "", 0),
+	})
+}
+
+// doRequest drives the server's mux directly. body may be a string (sent
+// verbatim, for malformed JSON cases) or any value to be marshalled.
+func doRequest(t *testing.T, s *Server, method, path, authHeader string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(b)
+	default:
+		dat, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("Failed to marshal request body: %v", err)
+		}
+		reader = bytes.NewBuffer(dat)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("Failed to decode response %q: %v", rec.Body.String(), err)
+	}
+}
+
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if rec.Code != want {
+		t.Fatalf("Expected status %d, got %d (body %s)", want, rec.Code, rec.Body.String())
+	}
+}
+
+type loggedInUser struct {
+	User
+	Token					string	`json:"token"`
+	RefreshToken	string	`json:"refresh_token"`
+}
+
+func (u loggedInUser) bearer() string {
+	return "Bearer " + u.Token
+}
+
+type userOptions struct {
+	username	string
+	admin			bool
+}
+
+type userOption func(*userOptions)
+
+// withUsername picks the new account's username instead of a generated one.
+func withUsername(username string) userOption {
+	return func(o *userOptions) {
+		o.username = username
+	}
+}
+
+// asAdmin promotes the new account through BootstrapAdmin before logging in,
+// so the token carries the admin role.
+func asAdmin() userOption {
+	return func(o *userOptions) {
+		o.admin = true
+	}
+}
+
+func createAndLogin(t *testing.T, s *Server, email, password string, opts ...userOption) loggedInUser {
+	t.Helper()
+
+	var o userOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	params := map[string]string{
+		"email":		email,
+		"password":	password,
+	}
+	if o.username != "" {
+		params["username"] = o.username
+	}
+	rec := doRequest(t, s, http.MethodPost, "/api/users", "", params)
+	expectStatus(t, rec, http.StatusCreated)
+
+	if o.admin {
+		if _, err := BootstrapAdmin(context.Background(), s.db, email, ""); err != nil {
+			t.Fatalf("Failed to bootstrap admin: %v", err)
+		}
+	}
+
+	rec = doRequest(t, s, http.MethodPost, "/api/login", "", map[string]string{
+		"email":		email,
+		"password":	password,
+	})
+	expectStatus(t, rec, http.StatusOK)
+
+	var user loggedInUser
+	decodeBody(t, rec, &user)
+	return user
+}
+
+func TestServersAreIsolated(t *testing.T) {
+	s1 := newTestServer(t)
+	s2 := newTestServer(t)
+
+	createAndLogin(t, s1, "a@example.com", "password1")
+
+	rec := doRequest(t, s2, http.MethodPost, "/api/login", "", map[string]string{
+		"email":		"a@example.com",
+		"password":	"password1",
+	})
+	expectStatus(t, rec, http.StatusUnauthorized)
+}
+
+func TestHandleReadiness(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := doRequest(t, s, http.MethodGet, "/api/healthz", "", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if rec.Body.String() != "OK" {
+		t.Fatalf("Expected body OK, got %q", rec.Body.String())
+	}
+}
+
+func TestHandleReset(t *testing.T) {
+	s := newTestServer(t)
+	createAndLogin(t, s, "a@example.com", "password1")
+	admin := createAndLogin(t, s, "admin@example.com", "password1", asAdmin())
+
+	rec := doRequest(t, s, http.MethodPost, "/admin/reset", admin.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	// Reset removes the admin too, so their token stops working.
+	rec = doRequest(t, s, http.MethodGet, "/admin/users", admin.bearer(), nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	users, err := s.db.GetUsersPage(context.Background(), database.GetUsersPageParams{PageLimit: 10})
+	if err != nil {
+		t.Fatalf("Failed to list users: %v", err)
+	}
+	if len(users) != 0 {
+		t.Fatalf("Expected no users after reset, got %d", len(users))
+	}
+}
+
+func TestHandleReset_ForbiddenOutsideDev(t *testing.T) {
+	s := newTestServer(t)
+	s.platform = "prod"
+	admin := createAndLogin(t, s, "admin@example.com", "password1", asAdmin())
+
+	rec := doRequest(t, s, http.MethodPost, "/admin/reset", admin.bearer(), nil)
+	expectStatus(t, rec, http.StatusForbidden)
+}
+
+func TestAdminRoutes_RequireAdmin(t *testing.T) {
+	s := newTestServer(t)
+	user := createAndLogin(t, s, "a@example.com", "password1")
+	admin := createAndLogin(t, s, "admin@example.com", "password1", asAdmin())
+
+	rec := doRequest(t, s, http.MethodGet, "/admin/metrics", "", nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = doRequest(t, s, http.MethodGet, "/admin/metrics", user.bearer(), nil)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = doRequest(t, s, http.MethodGet, "/admin/metrics", admin.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = doRequest(t, s, http.MethodPut, "/admin/users/"+user.ID.String()+"/role", admin.bearer(), map[string]string{
+		"role":	"admin",
+	})
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = doRequest(t, s, http.MethodPost, "/api/login", "", map[string]string{
+		"email":		"a@example.com",
+		"password":	"password1",
+	})
+	expectStatus(t, rec, http.StatusOK)
+
+	var promoted loggedInUser
+	decodeBody(t, rec, &promoted)
+	if promoted.Role != "admin" {
+		t.Fatalf("Expected promoted user to have admin role, got %q", promoted.Role)
+	}
+
+	rec = doRequest(t, s, http.MethodGet, "/admin/metrics", promoted.bearer(), nil)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = doRequest(t, s, http.MethodPut, "/admin/users/"+user.ID.String()+"/role", admin.bearer(), map[string]string{
+		"role":	"superuser",
+	})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	// Demotion applies to tokens already issued with the admin claim.
+	rec = doRequest(t, s, http.MethodPut, "/admin/users/"+user.ID.String()+"/role", admin.bearer(), map[string]string{
+		"role":	"user",
+	})
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = doRequest(t, s, http.MethodGet, "/admin/metrics", promoted.bearer(), nil)
+	expectStatus(t, rec, http.StatusForbidden)
+}
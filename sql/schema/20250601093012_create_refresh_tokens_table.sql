@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE refresh_tokens(
+  token       TEXT PRIMARY KEY,
+  created_at  TIMESTAMP NOT NULL,
+  updated_at  TIMESTAMP NOT NULL,
+  user_id     UUID NOT NULL,
+  expires_at  TIMESTAMP NOT NULL,
+  revoked_at  TIMESTAMP,
+  CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES  users(id)
+    ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE refresh_tokens;
+-- +goose StatementEnd
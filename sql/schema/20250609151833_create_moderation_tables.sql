@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE moderation_rules(
+  id          UUID PRIMARY KEY,
+  created_at  TIMESTAMP NOT NULL,
+  updated_at  TIMESTAMP NOT NULL,
+  word        TEXT UNIQUE NOT NULL,
+  action      TEXT NOT NULL CHECK (action IN ('mask', 'reject', 'flag'))
+);
+
+CREATE TABLE chirp_flags(
+  chirp_id    UUID PRIMARY KEY,
+  created_at  TIMESTAMP NOT NULL,
+  reason      TEXT NOT NULL,
+  CONSTRAINT fk_chirps
+    FOREIGN KEY (chirp_id)
+    REFERENCES  chirps(id)
+    ON DELETE CASCADE
+);
+
+INSERT INTO moderation_rules (id, created_at, updated_at, word, action)
+VALUES
+  (gen_random_uuid(), NOW(), NOW(), 'kerfuffle', 'mask'),
+  (gen_random_uuid(), NOW(), NOW(), 'sharbert', 'mask'),
+  (gen_random_uuid(), NOW(), NOW(), 'fornax', 'mask');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chirp_flags;
+DROP TABLE moderation_rules;
+-- +goose StatementEnd
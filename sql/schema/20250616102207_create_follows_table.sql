@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE follows(
+  follower_id   UUID NOT NULL,
+  followee_id   UUID NOT NULL,
+  created_at    TIMESTAMP NOT NULL,
+  PRIMARY KEY (follower_id, followee_id),
+  CONSTRAINT no_self_follow CHECK (follower_id <> followee_id),
+  CONSTRAINT fk_follower
+    FOREIGN KEY (follower_id)
+    REFERENCES  users(id)
+    ON DELETE CASCADE,
+  CONSTRAINT fk_followee
+    FOREIGN KEY (followee_id)
+    REFERENCES  users(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX idx_follows_follower_id_created_at ON follows (follower_id, created_at);
+CREATE INDEX idx_follows_followee_id_created_at ON follows (followee_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE follows;
+-- +goose StatementEnd
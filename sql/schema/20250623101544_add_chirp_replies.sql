@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chirps ADD COLUMN in_reply_to UUID REFERENCES chirps(id) ON DELETE SET NULL;
+CREATE INDEX idx_chirps_in_reply_to_created_at_id ON chirps (in_reply_to, created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_chirps_in_reply_to_created_at_id;
+ALTER TABLE chirps DROP COLUMN in_reply_to;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chirps ADD COLUMN rechirp_of UUID REFERENCES chirps(id) ON DELETE CASCADE;
+ALTER TABLE chirps ADD COLUMN quoted_chirp_id UUID;
+CREATE UNIQUE INDEX idx_chirps_user_id_rechirp_of ON chirps (user_id, rechirp_of);
+CREATE INDEX idx_chirps_rechirp_of ON chirps (rechirp_of);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_chirps_rechirp_of;
+DROP INDEX idx_chirps_user_id_rechirp_of;
+ALTER TABLE chirps DROP COLUMN quoted_chirp_id;
+ALTER TABLE chirps DROP COLUMN rechirp_of;
+-- +goose StatementEnd
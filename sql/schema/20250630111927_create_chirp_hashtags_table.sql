@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE chirp_hashtags(
+  chirp_id    UUID NOT NULL,
+  tag         TEXT NOT NULL,
+  PRIMARY KEY (chirp_id, tag),
+  CONSTRAINT fk_chirps
+    FOREIGN KEY (chirp_id)
+    REFERENCES  chirps(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX idx_chirp_hashtags_tag ON chirp_hashtags (tag);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chirp_hashtags;
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE chirp_mentions(
+  chirp_id      UUID NOT NULL,
+  user_id       UUID NOT NULL,
+  start_offset  INTEGER NOT NULL,
+  end_offset    INTEGER NOT NULL,
+  PRIMARY KEY (chirp_id, start_offset),
+  CONSTRAINT fk_chirps
+    FOREIGN KEY (chirp_id)
+    REFERENCES  chirps(id)
+    ON DELETE CASCADE,
+  CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES  users(id)
+    ON DELETE CASCADE
+);
+
+CREATE TABLE notifications(
+  id          UUID PRIMARY KEY,
+  created_at  TIMESTAMP NOT NULL,
+  user_id     UUID NOT NULL,
+  actor_id    UUID NOT NULL,
+  kind        TEXT NOT NULL CHECK (kind IN ('mention')),
+  chirp_id    UUID,
+  read_at     TIMESTAMP,
+  CONSTRAINT fk_users
+    FOREIGN KEY (user_id)
+    REFERENCES  users(id)
+    ON DELETE CASCADE,
+  CONSTRAINT fk_actors
+    FOREIGN KEY (actor_id)
+    REFERENCES  users(id)
+    ON DELETE CASCADE,
+  CONSTRAINT fk_chirps
+    FOREIGN KEY (chirp_id)
+    REFERENCES  chirps(id)
+    ON DELETE CASCADE
+);
+
+CREATE INDEX idx_notifications_user_id_created_at_id ON notifications (user_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE notifications;
+DROP TABLE chirp_mentions;
+-- +goose StatementEnd